@@ -4,6 +4,7 @@ export default {
     callWebhook,
 
     orderUpdateEndpoint: "order-update",
+    orderSeenEndpoint: "order-seen",
     userSettingsEndpoint: "user-settings",
 };
 
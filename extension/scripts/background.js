@@ -99,7 +99,7 @@ async function pollRunningOrders() {
         console.log(`Updated order: ${order.orderId} ${order.hashId}, ${order.status}, ${JSON.stringify(order.deliveryDetails)}`);
         const message = `[${order.orderId}] [${order.deliveryDetails?.deliveryLabel}] ${order.deliveryDetails?.deliveryLabel}`;
         await showBasicNotification("order-update", "Snack track 🚚", message);
-        await api.callWebhook(api.orderUpdateEndpoint, { order, slackId });
+        await api.callWebhook(api.orderUpdateEndpoint, { order: toOrderPayload(order), slackId });
     }
 
     // identify new orders that are not present in `runningOrders` and `state` is non-terminal
@@ -110,13 +110,22 @@ async function pollRunningOrders() {
             console.log(`New order: ${order.orderId} ${order.hashId}, ${order.status}, ${JSON.stringify(order.deliveryDetails)}`);
             const message = `[${order.orderId}] [${order.deliveryDetails?.deliveryLabel}] ${order.deliveryDetails?.deliveryLabel}`;
             showBasicNotification("new-order", "Snack track 🚚", message);
-            api.callWebhook(api.orderUpdateEndpoint, { order, slackId });
+            api.callWebhook(api.orderUpdateEndpoint, { order: toOrderPayload(order), slackId });
         }
         return isNewOrder;
     });
 
+    // report unchanged running orders too, so the server knows they are still being tracked
+    const unchangedOrders = orders.filter(order => {
+        const runningOrder = runningOrders?.find(runningOrder => runningOrder.hashId === order.hashId);
+        return runningOrder && !updatedOrders.includes(order);
+    });
+    for (const order of unchangedOrders) {
+        api.callWebhook(api.orderSeenEndpoint, { order: toOrderPayload(order), slackId });
+    }
+
     // append new orders to `runningOrders`
-    const finalRunningOrders = [...newOrders, ...updatedOrders];
+    const finalRunningOrders = [...newOrders, ...updatedOrders, ...unchangedOrders];
 
     // filter final running orders to remove any orders that have reached terminal state
     const filteredRunningOrders = finalRunningOrders.filter(order => isRunningOrder(order));
@@ -147,6 +156,82 @@ chrome.notifications.onButtonClicked.addListener((notificationId, buttonIndex) =
 
 /******************** UTIL ********************/
 
+// toOrderPayload maps a Zomato order to the fields the server reads, the raw
+// order is kept as is apart from the fields below
+function toOrderPayload(order) {
+    return {
+        ...order,
+        deliveryAddress: toDeliveryAddress(order),
+        expectedDeliveryTime: toExpectedDeliveryTime(order),
+        items: toItems(order),
+        bill: toBill(order),
+    };
+}
+
+// toItems returns the ordered dishes as `{ name, quantity, unitPrice, totalPrice }`
+// with prices in paise. The orders list only has a dish summary like
+// "1 x Paneer Tikka, 2 x Butter Naan", items parsed from it have no prices.
+function toItems(order) {
+    if (Array.isArray(order.items)) {
+        return order.items;
+    }
+    if (!order.dishString) {
+        return undefined;
+    }
+    return order.dishString.split(",").map(dish => {
+        const match = dish.trim().match(/^(\d+)\s*x\s*(.+)$/);
+        return match
+            ? { name: match[2].trim(), quantity: Number(match[1]) }
+            : { name: dish.trim(), quantity: 1 };
+    }).filter(item => item.name);
+}
+
+// toBill returns the order's `{ currency, total }` in paise, from the total cost
+// shown in the orders list, e.g. "₹1,234.50"
+function toBill(order) {
+    if (order.bill && typeof order.bill === "object") {
+        return order.bill;
+    }
+    const cost = order.totalCost ?? order.total;
+    if (cost === undefined || cost === null || cost === "") {
+        return undefined;
+    }
+    const total = Math.round(Number(String(cost).replace(/[^0-9.]/g, "")) * 100);
+    if (!total) {
+        return undefined;
+    }
+    return { currency: "INR", total };
+}
+
+// toExpectedDeliveryTime returns the delivery time promised for the order as an
+// RFC 3339 string, Zomato sends it as a unix timestamp or a date string
+function toExpectedDeliveryTime(order) {
+    let value = order.deliveryDetails?.expectedDeliveryTime ?? order.expectedDeliveryTime;
+    if (value === undefined || value === null || value === "") {
+        return undefined;
+    }
+    if (typeof value === "number" && value < 1e12) {
+        value *= 1000; // seconds
+    }
+    const date = new Date(value);
+    return isNaN(date.getTime()) ? undefined : date.toISOString();
+}
+
+// toDeliveryAddress returns the `{ id, label }` of the address the order is
+// delivered to, using the same id as the addresses picked in the popup.
+// Orders without an address id are sent without one and are not address filtered.
+function toDeliveryAddress(order) {
+    const address = order.deliveryDetails?.deliveryAddress ?? order.deliveryAddress ?? order.address;
+    const id = address?.id ?? address?.addressId ?? order.addressId;
+    if (id === undefined || id === null || id === "") {
+        return undefined;
+    }
+    return {
+        id: String(id),
+        label: address?.display_title ?? address?.displayTitle ?? address?.address ?? "",
+    };
+}
+
 function isRunningOrder(order) {
     return ![6, 7, 8].includes(order.status) && order.paymentStatus === 1;
 }
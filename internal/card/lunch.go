@@ -0,0 +1,67 @@
+package card
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// LunchSession renders a lunch session with its poll, participants and, while
+// it is open, the buttons to join, leave, vote and close it.
+// The returned string is a plain-text fallback used for notifications.
+func LunchSession(session *models.LunchSession) ([]slack.Block, string) {
+	cutoff := fmt.Sprintf("<!date^%d^{time}|%s>", session.Cutoff.Unix(), session.Cutoff.UTC().Format(time.Kitchen))
+	open := session.Status == models.LunchStatusOpen
+
+	var status string
+	switch session.Status {
+	case models.LunchStatusOpen:
+		status = "Join before " + cutoff + "."
+	case models.LunchStatusClosed:
+		status = "Cutoff passed, waiting for <@" + session.OrganiserId + "> to place the order."
+	case models.LunchStatusOrdered:
+		status = ":motor_scooter: The order is on its way."
+	case models.LunchStatusDelivered:
+		status = ":tada: Lunch is here!"
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Team lunch", true, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Organised by <@%s>. %s", session.OrganiserId, status), false, false), nil, nil),
+	}
+
+	for i, option := range session.Options {
+		text := fmt.Sprintf("*%s*  `%d`", option.Name, len(option.Votes))
+		if len(option.Votes) > 0 {
+			text += "\n" + util.Mentions(option.Votes)
+		}
+		var accessory *slack.Accessory
+		if open {
+			vote := slack.NewButtonBlockElement(shared.ActionLunchVote, fmt.Sprintf("%s:%d", session.SessionId, i), slack.NewTextBlockObject(slack.PlainTextType, "Vote", false, false))
+			accessory = slack.NewAccessory(vote)
+		}
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, accessory))
+	}
+
+	participants := "Nobody has joined yet."
+	if len(session.Participants) > 0 {
+		participants = fmt.Sprintf("%d joined: %s", len(session.Participants), util.Mentions(session.Participants))
+	}
+	blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, participants, false, false)))
+
+	if open {
+		join := slack.NewButtonBlockElement(shared.ActionLunchJoin, session.SessionId, slack.NewTextBlockObject(slack.PlainTextType, "Join", false, false))
+		join.Style = slack.StylePrimary
+		leave := slack.NewButtonBlockElement(shared.ActionLunchLeave, session.SessionId, slack.NewTextBlockObject(slack.PlainTextType, "Leave", false, false))
+		closeSession := slack.NewButtonBlockElement(shared.ActionLunchClose, session.SessionId, slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false))
+		closeSession.Style = slack.StyleDanger
+		blocks = append(blocks, slack.NewActionBlock("lunch_actions", join, leave, closeSession))
+	}
+
+	fallback := fmt.Sprintf("<@%s> is organising a team lunch. %s", session.OrganiserId, status)
+	return blocks, fallback
+}
@@ -0,0 +1,160 @@
+package card
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// maxSummaryItems is how many items are listed in the item summary before it is cut short
+const maxSummaryItems = 3
+
+// Order renders an order placed by userId as a Block Kit card, with a compact
+// item summary if showItems is set.
+// The returned string is a plain-text fallback used for notifications.
+func Order(userId string, o *models.Order, stage lifecycle.Stage, updatedAt time.Time, showItems bool) ([]slack.Block, string) {
+	restaurant := restaurantName(o)
+	label, message := o.DeliveryLabel, o.DeliveryMessage
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, restaurant, true, false)),
+	}
+
+	fields := []*slack.TextBlockObject{
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*Order*\n`%s`", o.OrderId), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, "*Status*\n"+statusBadge(stage, label), false, false),
+	}
+	if o.DeliveryAddress != nil && o.DeliveryAddress.Label != "" {
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, "*Deliver to*\n"+o.DeliveryAddress.Label, false, false))
+	}
+	if eta := o.ExpectedDeliveryTime; eta != nil && stage.IsActive() {
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*Expected by*\n<!date^%d^{time}|%s>", eta.Unix(), eta.UTC().Format(time.Kitchen)), false, false))
+	}
+	blocks = append(blocks, slack.NewSectionBlock(nil, fields, nil))
+
+	if progress := progressText(stage); progress != "" {
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, progress, false, false)))
+	}
+
+	if message != "" {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, message, false, false), nil, nil))
+	}
+
+	if summary := itemSummary(o); showItems && summary != "" {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, summary, false, false), nil, nil))
+	}
+
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Ordered by <@%s>", userId), false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Updated <!date^%d^{time}|%s>", updatedAt.Unix(), updatedAt.UTC().Format(time.Kitchen)), false, false),
+	))
+
+	if o.DeliveryAddress != nil && o.DeliveryAddress.Label != "" {
+		restaurant += " to _" + o.DeliveryAddress.Label + "_"
+	}
+	fallback := fmt.Sprintf("<@%s>'s order (`%s`) from %s is *%s* %s", userId, o.OrderId, restaurant, label, message)
+	return blocks, fallback
+}
+
+// Acknowledgement renders the "I've got it" button of a delivered order or, once
+// pressed, who picked the order up
+func Acknowledgement(record *models.OrderRecord) slack.Block {
+	if record.AckedAt != nil {
+		return slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(":raised_hands: Picked up by <@%s> <!date^%d^{time}|%s>", record.AckedBy, record.AckedAt.Unix(), record.AckedAt.UTC().Format(time.Kitchen)), false, false))
+	}
+	button := slack.NewButtonBlockElement(shared.ActionOrderAck, record.Provider+":"+record.UserId+":"+record.OrderId, slack.NewTextBlockObject(slack.PlainTextType, "I've got it", true, false))
+	button.Style = slack.StylePrimary
+	return slack.NewActionBlock("order_ack", button)
+}
+
+// Notice renders a highlighted line appended to an order card, e.g. when tracking was lost
+func Notice(text string) slack.Block {
+	return slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, ":warning: "+text, false, false))
+}
+
+// Reply renders the short line posted as a thread reply for a status change
+func Reply(o *models.Order, stage lifecycle.Stage) string {
+	return strings.TrimSpace(fmt.Sprintf("%s *%s* %s", statusEmoji(stage), o.DeliveryLabel, o.DeliveryMessage))
+}
+
+// itemSummary renders the ordered items and total, e.g. "2× Biryani, 1× Raita · *₹540.50*"
+func itemSummary(o *models.Order) string {
+	parts := make([]string, 0, maxSummaryItems+1)
+	for i, item := range o.Items {
+		if i == maxSummaryItems {
+			parts = append(parts, fmt.Sprintf("+%d more", len(o.Items)-maxSummaryItems))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d× %s", item.Quantity, item.Name))
+	}
+	summary := strings.Join(parts, ", ")
+
+	if o.Bill != nil && o.Bill.Total > 0 {
+		total := "*" + util.FormatAmount(o.Bill.Total, o.Bill.Currency) + "*"
+		if summary == "" {
+			return total
+		}
+		summary += " · " + total
+	}
+	return summary
+}
+
+func restaurantName(o *models.Order) string {
+	if o.RestaurantName == "" {
+		return "Your order"
+	}
+	return o.RestaurantName
+}
+
+func statusBadge(stage lifecycle.Stage, label string) string {
+	if label == "" {
+		label = stage.Label()
+	}
+	return statusEmoji(stage) + " " + label
+}
+
+func statusEmoji(stage lifecycle.Stage) string {
+	switch {
+	case stage == lifecycle.Delivered:
+		return ":white_check_mark:"
+	case stage == lifecycle.Cancelled || stage == lifecycle.Failed:
+		return ":x:"
+	case stage.IsActive():
+		return ":large_blue_circle:"
+	default:
+		return ":grey_question:"
+	}
+}
+
+// progressText renders the stages reached so far, e.g. "✓ Placed → ✓ Accepted → ◌ Preparing".
+// Orders that are not on the happy path (cancelled, failed, unknown) have no progress.
+func progressText(stage lifecycle.Stage) string {
+	current := -1
+	for i, s := range lifecycle.Progress {
+		if s == stage {
+			current = i
+		}
+	}
+	if current < 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(lifecycle.Progress))
+	for i, s := range lifecycle.Progress {
+		switch {
+		case i < current || i == current && s.IsTerminal():
+			parts = append(parts, ":white_check_mark: "+s.Label())
+		case i == current:
+			parts = append(parts, ":hourglass_flowing_sand: *"+s.Label()+"*")
+		default:
+			parts = append(parts, ":white_circle: "+s.Label())
+		}
+	}
+	return strings.Join(parts, "  →  ")
+}
@@ -0,0 +1,46 @@
+package card
+
+import (
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// maxSectionText is the most text Slack accepts in a section block
+const maxSectionText = 3000
+
+// Settings renders the user's settings, one section per group of settings, with a
+// button opening the settings modal. The returned string is a plain-text fallback
+// used for notifications.
+func Settings(user *models.User, intro string) ([]slack.Block, string) {
+	groups := util.GetSettingsGroups(user)
+	blocks := make([]slack.Block, 0, len(groups)+4)
+	if intro = strings.TrimSpace(intro); intro != "" {
+		blocks = append(blocks, section(intro))
+	}
+	for _, group := range groups {
+		blocks = append(blocks, section(group))
+	}
+	blocks = append(blocks, slack.NewDividerBlock(), section(util.GetSettingsHelp()))
+
+	edit := slack.NewButtonBlockElement(shared.ActionSettingsEdit, "", slack.NewTextBlockObject(slack.PlainTextType, "Edit settings", false, false))
+	edit.Style = slack.StylePrimary
+	blocks = append(blocks, slack.NewActionBlock("settings_actions", edit))
+
+	text := "Here are your settings."
+	if intro != "" {
+		text = intro + " " + text
+	}
+	return blocks, text
+}
+
+// section renders markdown text in a section block, cut short at Slack's limit
+func section(text string) slack.Block {
+	if runes := []rune(text); len(runes) > maxSectionText {
+		text = string(runes[:maxSectionText-1]) + "…"
+	}
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/diabolusgx/snack-track/internal/home"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/slack-go/slack"
 )
@@ -27,11 +28,35 @@ func NewCommandExecutor(s slack.SlashCommand) (CommandExecutor, error) {
 		return &StChannel{}, nil
 	case "/track":
 		return &TrackCommand{}, nil
+	case "/st-stats":
+		return &StStats{}, nil
+	case "/st-digest":
+		return &StDigest{}, nil
+	case "/st-lunch":
+		return &StLunch{}, nil
+	case "/st-split":
+		return &StSplit{}, nil
+	case "/st-owe":
+		return &StOwe{}, nil
+	case "/st-settle":
+		return &StSettle{}, nil
+	case "/st-notify":
+		return &StNotify{}, nil
+	case "/st-snooze":
+		return &StSnooze{}, nil
+	case "/st-vacation":
+		return &StVacation{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported command: %s", s.Command)
 	}
 }
 
+// republishHome refreshes the user's App Home tab after their settings changed,
+// call it after responding, the refresh runs in the background
+func republishHome(api *slack.Client, userId string) {
+	home.PublishAsync(api, userId)
+}
+
 // parseParams function takes an input string and an output struct (as an interface{}),
 // extracts parameters, and decodes them into the struct.
 func parseParams(input string, output interface{}) error {
@@ -2,20 +2,47 @@ package command
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
 	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
 	"github.com/diabolusgx/snack-track/internal/util"
 	"github.com/diabolusgx/snack-track/pkg/mongo"
 	"github.com/slack-go/slack"
 )
 
+// channelMentionRegex matches escaped channel mentions like <#C123|general>
+var channelMentionRegex = regexp.MustCompile(`<#([A-Z0-9]+)(?:\|[^>]*)?>`)
+
+const stChannelUsage = "Usage:\n" +
+	"- `/st-channel add [#channel|dm] [--stages=placed,delivered]` posts order updates to the channel (this one by default) or your DM, optionally only for some stages\n" +
+	"- `/st-channel remove [#channel|dm]` stops posting there\n" +
+	"- `/st-channel list` shows where order updates are posted"
+
+// StChannel manages where a user's order updates are posted
 type StChannel struct {
+	Stages string `mapstructure:"stages"`
 }
 
 func (t *StChannel) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	// a bare `/st-channel` adds the current channel, as it always did
+	subCommand := "add"
+	if fields := strings.Fields(command.Text); len(fields) > 0 && !strings.HasPrefix(fields[0], "--") && !channelMentionRegex.MatchString(fields[0]) && fields[0] != shared.DestinationDM {
+		subCommand = fields[0]
+	}
+
+	var user *models.User
 	filters := mongo.Filters{
 		{
 			Key:      "user_id",
@@ -24,41 +51,110 @@ func (t *StChannel) Execute(ctx context.Context, api *slack.Client, command *sla
 			Operator: mongo.EQUAL,
 		},
 	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		user = &models.User{UserId: command.UserID}
+	} else if err != nil {
+		log.Printf("[StChannel] Failed to get user: %v\n", err)
+		return err
+	}
+
+	destination := &models.Destination{Type: shared.DestinationChannel, ChannelId: command.ChannelID}
+	if match := channelMentionRegex.FindStringSubmatch(command.Text); match != nil {
+		destination.ChannelId = match[1]
+	} else if strings.Contains(" "+command.Text+" ", " dm ") {
+		destination = &models.Destination{Type: shared.DestinationDM}
+	}
+
+	// the legacy channel becomes the first destination once routing is edited
+	destinations := util.GetDestinations(user)
+	if len(user.Destinations) == 0 && user.ChannelId == "" {
+		destinations = nil
+	}
+
+	var msg string
+	switch subCommand {
+	case "list":
+		msg = "Order updates are sent to:\n"
+		for _, d := range util.GetDestinations(user) {
+			msg += "- " + util.FormatDestination(d) + "\n"
+		}
+		sendResponse(w, msg)
+		return nil
+	case "add":
+		if t.Stages != "" {
+			destination.Stages, err = lifecycle.ParseAll(strings.Split(t.Stages, ","))
+			if err != nil {
+				sendResponse(w, fmt.Sprintf("%v, use any of `%s`", err, stageNames()))
+				return nil
+			}
+		}
+		destinations = removeDestination(destinations, destination)
+		destinations = append(destinations, destination)
+		msg = "Order updates will be sent to " + util.FormatDestination(destination)
+	case "remove":
+		remaining := removeDestination(destinations, destination)
+		if len(remaining) == len(destinations) {
+			sendResponse(w, "Order updates are not sent to "+util.FormatDestination(destination)+" anyway.")
+			return nil
+		}
+		destinations = remaining
+		msg = "Order updates will no longer be sent there."
+		if len(destinations) == 0 {
+			msg += " You'll get them by direct message until you add a channel."
+		}
+	default:
+		sendResponse(w, stChannelUsage)
+		return nil
+	}
+
 	updates := mongo.Updates{
+		{
+			Key:            "destinations",
+			Value:          destinations,
+			UpdateOperator: mongo.SET,
+		},
 		{
 			Key:            "channel_id",
-			Value:          command.ChannelID,
-			Type:           mongo.STRING_ARRAY,
+			Value:          "",
+			Type:           mongo.STRING,
 			UpdateOperator: mongo.SET,
 		},
 		{
 			Key:            "team_domain",
 			Value:          command.TeamDomain,
-			Type:           mongo.STRING_ARRAY,
+			Type:           mongo.STRING,
 			UpdateOperator: mongo.SET,
 		},
 	}
-	err := env.MongoClient().Upsert(ctx, env.MongoUsersCollectionName, filters, updates)
+	err = env.MongoClient().Upsert(ctx, env.MongoUsersCollectionName, filters, updates)
 	if err != nil {
-		log.Printf("[UserSettings] Failed to upsert user: %v\n", err)
+		log.Printf("[StChannel] Failed to upsert user: %v\n", err)
 		return err
 	}
+	user.Destinations = destinations
+	user.ChannelId = ""
 
-	var user *models.User
-	filters = mongo.Filters{
-		{
-			Key:      "user_id",
-			Value:    command.UserID,
-			Type:     mongo.STRING,
-			Operator: mongo.EQUAL,
-		},
-	}
-	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
-	if err != nil {
-		log.Printf("[StSettings] Failed to get user: %v\n", err)
-		return err
+	sendResponse(w, msg+"\n\n"+util.GetSlackMsgForSettings(user))
+	republishHome(api, command.UserID)
+	return nil
+}
+
+// removeDestination drops the destination posting to the same conversation as d
+func removeDestination(destinations []*models.Destination, d *models.Destination) []*models.Destination {
+	result := make([]*models.Destination, 0, len(destinations))
+	for _, existing := range destinations {
+		if existing.Type != d.Type || existing.ChannelId != d.ChannelId {
+			result = append(result, existing)
+		}
 	}
+	return result
+}
 
-	sendResponse(w, "Your channel has been set to <#"+command.ChannelID+">"+"\n\n"+util.GetSlackMsgForSettings(user))
-	return nil
+func stageNames() string {
+	names := make([]string, 0, len(lifecycle.Stages))
+	for _, stage := range lifecycle.Stages {
+		names = append(names, string(stage))
+	}
+	return strings.Join(names, "`, `")
 }
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+type StDigest struct {
+	Weekly  string `mapstructure:"weekly"`
+	Monthly string `mapstructure:"monthly"`
+	To      string `mapstructure:"to"`
+}
+
+func (t *StDigest) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		sendResponse(w, "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started.")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[StDigest] Failed to get user: %v\n", err)
+		return err
+	}
+
+	digests := map[string]bool{}
+	for _, digest := range user.Digests {
+		digests[digest] = true
+	}
+	for digest, value := range map[string]string{shared.DigestWeekly: t.Weekly, shared.DigestMonthly: t.Monthly} {
+		if value == "" {
+			continue
+		}
+		if value != "on" && value != "off" {
+			sendResponse(w, "`--"+digest+"` must be either `on` or `off`")
+			return nil
+		}
+		digests[digest] = value == "on"
+	}
+
+	updates := mongo.Updates{}
+	if t.Weekly != "" || t.Monthly != "" {
+		// keep a stable order for the settings message
+		enabled := []string{}
+		for _, digest := range []string{shared.DigestWeekly, shared.DigestMonthly} {
+			if digests[digest] {
+				enabled = append(enabled, digest)
+			}
+		}
+		updates.Append(mongo.Update{
+			Key:            "digests",
+			Value:          enabled,
+			Type:           mongo.STRING_ARRAY,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if t.To != "" {
+		if t.To != shared.DigestDeliveryChannel && t.To != shared.DigestDeliveryDM {
+			sendResponse(w, "`--to` must be either `channel` or `dm`")
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "digest_delivery",
+			Value:          t.To,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if len(updates) > 0 {
+		err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, updates, &user)
+		if err != nil {
+			log.Printf("[StDigest] Failed to update digests: %v\n", err)
+			return err
+		}
+	}
+
+	sendResponse(w, util.GetSlackMsgForSettings(user))
+	republishHome(api, command.UserID)
+	return nil
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lunch"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// defaultLunchCutoff is used when no `--cutoff` is given
+	defaultLunchCutoff = 30 * time.Minute
+	// maxLunchOptions keeps the poll within a readable size
+	maxLunchOptions = 10
+)
+
+var lunchParamsRegex = regexp.MustCompile(`--\w+=[^\s]+`)
+
+// StLunch opens a lunch session in the channel, restaurant suggestions are the
+// comma separated text after the params, e.g. `/st-lunch --cutoff=12:30 Pizza Hut, Subway`
+type StLunch struct {
+	Cutoff string `mapstructure:"cutoff"`
+}
+
+func (t *StLunch) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	var options []*models.LunchOption
+	for _, name := range strings.Split(lunchParamsRegex.ReplaceAllString(command.Text, ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			options = append(options, &models.LunchOption{Name: name})
+		}
+	}
+	if len(options) == 0 {
+		sendResponse(w, "Please suggest at least one restaurant, e.g. `/st-lunch --cutoff=12:30 Pizza Hut, Subway`")
+		return nil
+	}
+	if len(options) > maxLunchOptions {
+		sendResponse(w, "Please suggest at most 10 restaurants")
+		return nil
+	}
+
+	// organisers don't need Snack Track settings, their timezone is looked up from Slack then
+	user := &models.User{UserId: command.UserID}
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err != nil && err != mongo.NoItemFound {
+		log.Printf("[StLunch] Failed to get user: %v\n", err)
+		return err
+	}
+
+	now := time.Now().In(util.GetUserLocation(ctx, api, user))
+	cutoff, ok := parseCutoff(t.Cutoff, now)
+	if !ok {
+		sendResponse(w, "`--cutoff` must be a later time today like `--cutoff=12:30` or a duration like `--cutoff=45m`")
+		return nil
+	}
+
+	session := &models.LunchSession{
+		TeamId:      command.TeamID,
+		ChannelId:   command.ChannelID,
+		OrganiserId: command.UserID,
+		Cutoff:      cutoff,
+		Options:     options,
+	}
+	if err := lunch.Open(ctx, api, session); err != nil {
+		log.Printf("[StLunch] Failed to open lunch session: %v\n", err)
+		sendResponse(w, "Failed to open the lunch session, please make sure Snack Track is added to this channel.")
+		return nil
+	}
+
+	sendResponse(w, "Your lunch session is open. Place your order once the cutoff passes and it will be linked to the session.")
+	return nil
+}
+
+// parseCutoff reads a cutoff given as a time of day in now's location or as a duration from now
+func parseCutoff(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return now.Add(defaultLunchCutoff), true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d), d > 0
+	}
+	t, err := time.Parse(shared.ScheduleTimeFormat, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	return cutoff, cutoff.After(now)
+}
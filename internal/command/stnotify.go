@@ -0,0 +1,98 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+const stNotifyUsage = "Usage:\n" +
+	"- `/st-notify stages out_for_delivery,delivered` only posts updates for these stages, `/st-notify stages all` posts every update\n" +
+	"- `/st-notify mute <restaurant>` stops posting orders from the restaurant\n" +
+	"- `/st-notify unmute <restaurant>` posts them again"
+
+// StNotify edits which order updates are posted, by stage and by restaurant
+type StNotify struct {
+}
+
+func (t *StNotify) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	subCommand, arg, _ := strings.Cut(strings.TrimSpace(command.Text), " ")
+	arg = strings.TrimSpace(arg)
+
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		sendResponse(w, "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started.")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[StNotify] Failed to get user: %v\n", err)
+		return err
+	}
+
+	var update mongo.Update
+	switch subCommand {
+	case "stages":
+		stages := []lifecycle.Stage{}
+		if arg != "all" {
+			stages, err = lifecycle.ParseAll(strings.Split(arg, ","))
+			if err != nil || len(stages) == 0 {
+				sendResponse(w, fmt.Sprintf("Stages must be `all` or any of `%s`", stageNames()))
+				return nil
+			}
+		}
+		update = mongo.Update{
+			Key:            "notify_stages",
+			Value:          stages,
+			UpdateOperator: mongo.SET,
+		}
+	case "mute", "unmute":
+		if arg == "" {
+			sendResponse(w, stNotifyUsage)
+			return nil
+		}
+		muted := slices.DeleteFunc(user.MutedRestaurants, func(name string) bool {
+			return strings.EqualFold(name, arg)
+		})
+		if subCommand == "mute" {
+			muted = append(muted, arg)
+		}
+		update = mongo.Update{
+			Key:            "muted_restaurants",
+			Value:          muted,
+			Type:           mongo.STRING_ARRAY,
+			UpdateOperator: mongo.SET,
+		}
+	default:
+		sendResponse(w, stNotifyUsage+"\n\n"+util.GetSlackMsgForSettings(user))
+		return nil
+	}
+
+	err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, mongo.Updates{update}, &user)
+	if err != nil {
+		log.Printf("[StNotify] Failed to update notification preferences: %v\n", err)
+		return err
+	}
+
+	sendResponse(w, util.GetSlackMsgForSettings(user))
+	republishHome(api, command.UserID)
+	return nil
+}
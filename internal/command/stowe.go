@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/split"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// StOwe lists the user's unsettled balances in the workspace
+type StOwe struct {
+}
+
+func (t *StOwe) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	balances, err := split.Balances(ctx, command.TeamID, command.UserID)
+	if err != nil {
+		log.Printf("[StOwe] Failed to get balances: %v\n", err)
+		return err
+	}
+	if len(balances) == 0 {
+		sendResponse(w, "You're all square, nobody owes anybody. :handshake:")
+		return nil
+	}
+
+	owed, owing := &strings.Builder{}, &strings.Builder{}
+	for _, balance := range balances {
+		if balance.Amount > 0 {
+			owed.WriteString(fmt.Sprintf("- <@%s> owes you %s\n", balance.UserId, util.FormatAmount(balance.Amount, balance.Currency)))
+		} else {
+			owing.WriteString(fmt.Sprintf("- you owe <@%s> %s\n", balance.UserId, util.FormatAmount(-balance.Amount, balance.Currency)))
+		}
+	}
+
+	msg := ""
+	if owed.Len() > 0 {
+		msg += "*Owed to you:*\n" + owed.String()
+	}
+	if owing.Len() > 0 {
+		msg += "*You owe:*\n" + owing.String()
+	}
+	msg += "\nOnce you've been paid back, use `/st-settle @teammate` to clear their debt."
+	sendResponse(w, msg)
+	return nil
+}
@@ -2,20 +2,46 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/diabolusgx/snack-track/internal/card"
 	"github.com/diabolusgx/snack-track/internal/env"
 	"github.com/diabolusgx/snack-track/internal/models"
-	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/internal/settings"
 	"github.com/diabolusgx/snack-track/pkg/mongo"
 	"github.com/slack-go/slack"
 )
 
 type StSettings struct {
+	Mode      string `mapstructure:"mode"`
+	Items     string `mapstructure:"items"`
+	Reminder  string `mapstructure:"reminder"`
+	Reminders string `mapstructure:"reminders"`
+	Backup    string `mapstructure:"backup"`
 }
 
 func (t *StSettings) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	// `--edit` takes no value, parseParams only picks up `--key=value`
+	if slices.Contains(strings.Fields(command.Text), "--edit") {
+		if err := settings.OpenModal(ctx, api, command.TriggerID, command.UserID); err != nil {
+			log.Printf("[StSettings] Failed to open settings modal: %v\n", err)
+			return err
+		}
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
 	var user *models.User
 	filters := mongo.Filters{
 		{
@@ -25,7 +51,7 @@ func (t *StSettings) Execute(ctx context.Context, api *slack.Client, command *sl
 			Operator: mongo.EQUAL,
 		},
 	}
-	err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
 	if err == mongo.NoItemFound {
 		sendResponse(w, "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started.")
 		return nil
@@ -35,6 +61,94 @@ func (t *StSettings) Execute(ctx context.Context, api *slack.Client, command *sl
 		return err
 	}
 
-	sendResponse(w, util.GetSlackMsgForSettings(user))
+	updates := mongo.Updates{}
+	if t.Mode != "" {
+		if !order.IsValidMessageMode(t.Mode) {
+			sendResponse(w, "`--mode` must be one of `edit`, `thread` or `legacy`")
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "message_mode",
+			Value:          t.Mode,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if t.Items != "" {
+		if t.Items != "on" && t.Items != "off" {
+			sendResponse(w, "`--items` must be either `on` or `off`")
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "show_items",
+			Value:          t.Items == "on",
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if t.Reminder != "" {
+		minutes, err := strconv.Atoi(t.Reminder)
+		if t.Reminder != "off" && (err != nil || minutes < 1) {
+			sendResponse(w, "`--reminder` must be a number of minutes or `off`")
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "reminders_off",
+			Value:          t.Reminder == "off",
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		})
+		if minutes > 0 {
+			updates.Append(mongo.Update{
+				Key:            "reminder_minutes",
+				Value:          minutes,
+				UpdateOperator: mongo.SET,
+			})
+		}
+	}
+	if t.Reminders != "" {
+		limit, err := strconv.Atoi(t.Reminders)
+		if err != nil || limit < 1 {
+			sendResponse(w, "`--reminders` must be the number of reminders to send, at least 1")
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "reminder_limit",
+			Value:          limit,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if t.Backup != "" {
+		backup := ""
+		if t.Backup != "off" {
+			match := mentionRegex.FindStringSubmatch(t.Backup)
+			if match == nil {
+				sendResponse(w, "`--backup` must mention a teammate like `--backup=@teammate`, or be `off`")
+				return nil
+			}
+			backup = match[1]
+		}
+		updates.Append(mongo.Update{
+			Key:            "reminder_backup",
+			Value:          backup,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if len(updates) > 0 {
+		err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, updates, &user)
+		if err != nil {
+			log.Printf("[StSettings] Failed to update settings: %v\n", err)
+			return err
+		}
+	}
+
+	blocks, text := card.Settings(user, "")
+	b, err := json.Marshal(&slack.Msg{Text: text, Blocks: slack.Blocks{BlockSet: blocks}})
+	if err != nil {
+		return err
+	}
+	sendResponse(w, string(b))
+	republishHome(api, command.UserID)
 	return nil
 }
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/split"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// StSettle clears what the mentioned teammates owe the user, only creditors can settle a debt
+type StSettle struct {
+}
+
+func (t *StSettle) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	matches := mentionRegex.FindAllStringSubmatch(command.Text, -1)
+	if len(matches) == 0 {
+		sendResponse(w, "Usage: `/st-settle @teammate`, marks what they owe you, net of what you owe them, as paid.")
+		return nil
+	}
+
+	msg := ""
+	for _, match := range matches {
+		debtorId := match[1]
+		settled, err := split.Settle(ctx, command.TeamID, command.UserID, debtorId)
+		if err != nil {
+			log.Printf("[StSettle] Failed to settle debts of %s: %v\n", debtorId, err)
+			return err
+		}
+		if len(settled) == 0 {
+			msg += fmt.Sprintf("<@%s> doesn't owe you anything.\n", debtorId)
+			continue
+		}
+		amounts := make([]string, 0, len(settled))
+		for _, balance := range settled {
+			amounts = append(amounts, util.FormatAmount(balance.Amount, balance.Currency))
+		}
+		msg += fmt.Sprintf("Settled the %s <@%s> owed you.\n", strings.Join(amounts, " and "), debtorId)
+	}
+	sendResponse(w, msg)
+	return nil
+}
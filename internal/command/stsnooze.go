@@ -0,0 +1,141 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+const stSnoozeUsage = "Usage: `/st-snooze 2h`, `/st-snooze --until=18:00` or `/st-snooze off`.\n" +
+	"Add `--delivered=on` to still get delivered updates while snoozed."
+
+// StSnooze holds back order updates until a deadline, they are summarised once it passes
+type StSnooze struct {
+	Until     string `mapstructure:"until"`
+	Delivered string `mapstructure:"delivered"`
+}
+
+func (t *StSnooze) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		sendResponse(w, "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started.")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[StSnooze] Failed to get user: %v\n", err)
+		return err
+	}
+
+	now := time.Now().In(util.GetUserLocation(ctx, api, user))
+	updates := mongo.Updates{}
+
+	// the duration or `off` is the first word that isn't a param
+	var arg string
+	for _, field := range strings.Fields(command.Text) {
+		if !strings.HasPrefix(field, "--") {
+			arg = field
+			break
+		}
+	}
+
+	switch {
+	case arg == "off":
+		updates.Append(mongo.Update{
+			Key:            "snoozed_until",
+			Value:          nil,
+			UpdateOperator: mongo.SET,
+		})
+	case arg != "":
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			sendResponse(w, stSnoozeUsage)
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "snoozed_until",
+			Value:          now.Add(d),
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		})
+	case t.Until != "":
+		until, err := time.Parse(shared.ScheduleTimeFormat, t.Until)
+		if err != nil {
+			sendResponse(w, stSnoozeUsage)
+			return nil
+		}
+		// a time already passed today means tomorrow
+		deadline := time.Date(now.Year(), now.Month(), now.Day(), until.Hour(), until.Minute(), 0, 0, now.Location())
+		if !deadline.After(now) {
+			deadline = deadline.AddDate(0, 0, 1)
+		}
+		updates.Append(mongo.Update{
+			Key:            "snoozed_until",
+			Value:          deadline,
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		})
+	}
+
+	if t.Delivered != "" {
+		if t.Delivered != "on" && t.Delivered != "off" {
+			sendResponse(w, "`--delivered` must be either `on` or `off`")
+			return nil
+		}
+		updates.Append(mongo.Update{
+			Key:            "snooze_breakthrough",
+			Value:          t.Delivered == "on",
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		})
+	}
+
+	if len(updates) == 0 {
+		sendResponse(w, snoozeStatus(user, now)+"\n"+stSnoozeUsage)
+		return nil
+	}
+	err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, updates, &user)
+	if err != nil {
+		log.Printf("[StSnooze] Failed to update snooze: %v\n", err)
+		return err
+	}
+
+	sendResponse(w, snoozeStatus(user, now))
+	republishHome(api, command.UserID)
+	return nil
+}
+
+func snoozeStatus(user *models.User, now time.Time) string {
+	msg := "Snooze is off, order updates are posted right away (unless Slack's Do Not Disturb is on)."
+	if user.SnoozedUntil != nil && user.SnoozedUntil.After(now) {
+		until := *user.SnoozedUntil
+		msg = fmt.Sprintf(":zzz: Order updates are snoozed until <!date^%d^{date_short_pretty} {time}|%s>, you'll get a summary by DM afterwards.", until.Unix(), until.UTC().Format(time.Kitchen))
+	}
+	if user.SnoozeBreakthrough {
+		msg += " Delivered updates still break through."
+	}
+	return msg
+}
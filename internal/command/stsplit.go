@@ -0,0 +1,163 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/internal/split"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// mentionRegex matches escaped user mentions like <@U123|bob>, optionally followed by =<spec>
+var mentionRegex = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|[^>]*)?>(?:=([^\s]+))?`)
+
+const splitUsage = "Usage: `/st-split --order=<order id> @teammate=items:1,3 @teammate=share:2 @teammate`\n" +
+	"Item numbers refer to the list above. Items nobody is assigned to are divided by shares, " +
+	"everyone mentioned without a spec and you have one share. Taxes, fees and discounts follow each person's part."
+
+// StSplit divides a stored order's bill between the payer and mentioned teammates
+// and records what each teammate owes in the workspace ledger
+type StSplit struct {
+	Order string `mapstructure:"order"`
+}
+
+func (t *StSplit) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	if t.Order == "" {
+		sendResponse(w, splitUsage)
+		return nil
+	}
+	record, err := order.FindForUser(ctx, command.UserID, t.Order)
+	if err == mongo.NoItemFound {
+		sendResponse(w, fmt.Sprintf("Couldn't find your order `%s`, only orders tracked by Snack Track can be split.", t.Order))
+		return nil
+	}
+	if err != nil {
+		log.Printf("[StSplit] Failed to get order: %v\n", err)
+		return err
+	}
+
+	matches := mentionRegex.FindAllStringSubmatch(command.Text, -1)
+	if len(matches) == 0 {
+		sendResponse(w, itemList(record.Order)+"\n"+splitUsage)
+		return nil
+	}
+
+	participants := []*models.SplitParticipant{}
+	payerMentioned := false
+	mentioned := map[string]bool{}
+	for _, match := range matches {
+		if mentioned[match[1]] {
+			sendResponse(w, fmt.Sprintf("<@%s> is mentioned more than once, give everyone a single spec like `@teammate=items:1,3`.\n%s", match[1], splitUsage))
+			return nil
+		}
+		mentioned[match[1]] = true
+		participant, err := parseParticipant(match[1], match[2])
+		if err != nil {
+			sendResponse(w, err.Error()+"\n"+splitUsage)
+			return nil
+		}
+		payerMentioned = payerMentioned || participant.UserId == command.UserID
+		participants = append(participants, participant)
+	}
+	if !payerMentioned {
+		participants = append(participants, &models.SplitParticipant{UserId: command.UserID, Shares: 1})
+	}
+
+	if err := split.Compute(record.Order, participants); err == split.ErrNoBill {
+		sendResponse(w, fmt.Sprintf("Order `%s` was reported without a bill, so it can't be split. Bills are sent from version %s of the Snack Track extension, please update it for your next orders.", t.Order, util.BillExtensionVersion))
+		return nil
+	} else if err != nil {
+		sendResponse(w, fmt.Sprintf("Couldn't split order `%s`: %v", t.Order, err))
+		return nil
+	}
+
+	s := &models.Split{
+		PayerId:      command.UserID,
+		Currency:     record.Order.Bill.Currency,
+		Participants: participants,
+		CreatedAt:    time.Now(),
+	}
+	if err := order.SaveSplit(ctx, record, s); err != nil {
+		log.Printf("[StSplit] Failed to save split: %v\n", err)
+		return err
+	}
+	paid, err := split.RecordDebts(ctx, command.TeamID, record, s)
+	if err != nil {
+		log.Printf("[StSplit] Failed to record debts: %v\n", err)
+		return err
+	}
+
+	strBuilder := &strings.Builder{}
+	strBuilder.WriteString(fmt.Sprintf("Order `%s` (%s) is split:\n", t.Order, util.FormatAmount(record.Order.Bill.Total, s.Currency)))
+	for _, participant := range participants {
+		strBuilder.WriteString(fmt.Sprintf("- <@%s>: %s\n", participant.UserId, util.FormatAmount(participant.Amount, s.Currency)))
+	}
+	strBuilder.WriteString("\nWhat your teammates owe you was added to the ledger, see `/st-owe`.")
+	if len(paid) > 0 {
+		strBuilder.WriteString(fmt.Sprintf("\n%s already settled this order with you and weren't charged again.", util.Mentions(paid)))
+	}
+	sendResponse(w, strBuilder.String())
+	return nil
+}
+
+// parseParticipant reads a participant spec like "items:1,3" or "share:2", no spec is one share
+func parseParticipant(userId, spec string) (*models.SplitParticipant, error) {
+	participant := &models.SplitParticipant{UserId: userId}
+	kind, value, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "":
+		participant.Shares = 1
+	case "items":
+		for _, n := range strings.Split(value, ",") {
+			item, err := strconv.Atoi(n)
+			if err != nil {
+				return nil, fmt.Errorf("`%s` is not a valid item number for <@%s>", n, userId)
+			}
+			participant.Items = append(participant.Items, item)
+		}
+	case "share":
+		shares, err := strconv.Atoi(value)
+		if err != nil || shares < 1 {
+			return nil, fmt.Errorf("`%s` is not a valid share for <@%s>", value, userId)
+		}
+		participant.Shares = shares
+	default:
+		return nil, fmt.Errorf("`%s` is not a valid split for <@%s>", spec, userId)
+	}
+	return participant, nil
+}
+
+// itemList renders the order's numbered items for picking them in a split
+func itemList(o *models.Order) string {
+	if len(o.Items) == 0 {
+		return "This order has no items, it can only be split by shares.\n"
+	}
+	currency := ""
+	if o.Bill != nil {
+		currency = o.Bill.Currency
+	}
+	strBuilder := &strings.Builder{}
+	for i, item := range o.Items {
+		if item.TotalPrice == 0 {
+			strBuilder.WriteString(fmt.Sprintf("%d. %d× %s\n", i+1, item.Quantity, item.Name))
+			continue
+		}
+		strBuilder.WriteString(fmt.Sprintf("%d. %d× %s — %s\n", i+1, item.Quantity, item.Name, util.FormatAmount(item.TotalPrice, currency)))
+	}
+	return strBuilder.String()
+}
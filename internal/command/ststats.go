@@ -0,0 +1,72 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/stats"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// statsPeriods maps `--period` values to how far back stats are aggregated
+var statsPeriods = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+}
+
+type StStats struct {
+	Period string `mapstructure:"period"`
+}
+
+func (t *StStats) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	if t.Period == "" {
+		t.Period = "month"
+	}
+	period, ok := statsPeriods[t.Period]
+	if !ok {
+		sendResponse(w, "`--period` must be one of `week`, `month` or `year`")
+		return nil
+	}
+
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		sendResponse(w, "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started.")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[StStats] Failed to get user: %v\n", err)
+		return err
+	}
+
+	to := time.Now()
+	summary, err := stats.ForUser(ctx, command.UserID, to.Add(-period), to, util.GetUserLocation(ctx, api, user))
+	if err != nil {
+		log.Printf("[StStats] Failed to aggregate stats: %v\n", err)
+		return err
+	}
+
+	sendResponse(w, fmt.Sprintf("Here are your order stats for the last %s:\n%s", t.Period, stats.Format(summary)))
+	return nil
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+const stVacationUsage = "Usage: `/st-vacation --from=2024-12-23 --to=2025-01-02` turns order updates off on those days (`--from` defaults to today), `/st-vacation off` ends it early."
+
+// StVacation turns order updates off across a range of days without touching the schedule
+type StVacation struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+func (t *StVacation) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
+	err := parseParams(command.Text, &t)
+	if err != nil {
+		return err
+	}
+
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		sendResponse(w, "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started.")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[StVacation] Failed to get user: %v\n", err)
+		return err
+	}
+
+	var vacation *models.Vacation
+	switch {
+	case strings.TrimSpace(command.Text) == "off":
+	case t.To != "":
+		today := time.Now().In(util.GetUserLocation(ctx, api, user)).Format(shared.VacationDateFormat)
+		if t.From == "" {
+			t.From = today
+		}
+		from, fromErr := time.Parse(shared.VacationDateFormat, t.From)
+		to, toErr := time.Parse(shared.VacationDateFormat, t.To)
+		if fromErr != nil || toErr != nil {
+			sendResponse(w, "Dates must look like `2024-12-23`.\n"+stVacationUsage)
+			return nil
+		}
+		if to.Before(from) || t.To < today {
+			sendResponse(w, "`--to` must be on or after `--from` and not in the past")
+			return nil
+		}
+		vacation = &models.Vacation{From: t.From, To: t.To}
+	default:
+		sendResponse(w, stVacationUsage)
+		return nil
+	}
+
+	updates := mongo.Updates{
+		{
+			Key:            "vacation",
+			Value:          vacation,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, updates, &user)
+	if err != nil {
+		log.Printf("[StVacation] Failed to update vacation: %v\n", err)
+		return err
+	}
+
+	sendResponse(w, util.GetSlackMsgForSettings(user))
+	republishHome(api, command.UserID)
+	return nil
+}
@@ -2,93 +2,175 @@ package command
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/diabolusgx/snack-track/internal/env"
 	"github.com/diabolusgx/snack-track/internal/models"
-	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/schedule"
 	"github.com/diabolusgx/snack-track/pkg/mongo"
 	"github.com/slack-go/slack"
 )
 
+// TrackCommand manages the user's schedule windows:
+// `/track add --from=09:00 --to=17:00 [--days=mon-fri] [--tz=Asia/Kolkata]`,
+// `/track list`, `/track remove <n>` and `/track clear`
 type TrackCommand struct {
 	From string `mapstructure:"from"`
 	To   string `mapstructure:"to"`
+	Days string `mapstructure:"days"`
+	Tz   string `mapstructure:"tz"`
 }
 
+const trackUsage = "Usage: `/track add --from=09:00 --to=17:00 [--days=mon-fri] [--tz=Asia/Kolkata]`, `/track list`, `/track remove <n>` or `/track clear`"
+
 func (t *TrackCommand) Execute(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter) error {
 	err := parseParams(command.Text, &t)
 	if err != nil {
 		return err
 	}
 
-	invalidTimeMsg := "`--from` and `--to` params must contain valid time like `--from=09:00` and `--to=17:00`"
+	// `/track --from=.. --to=..` without a subcommand adds a window, as it always did
+	args := strings.Fields(command.Text)
+	subcommand := "list"
+	if len(args) > 0 {
+		subcommand = strings.ToLower(args[0])
+		if strings.HasPrefix(subcommand, "--") {
+			subcommand = "add"
+		}
+	}
+
+	var user *models.User
+	keyFilters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    command.UserID,
+			Operator: mongo.EQUAL,
+			Type:     mongo.STRING,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, keyFilters, nil, &user)
+	if err != nil && err != mongo.NoItemFound {
+		log.Printf("[ERROR] failed to get user from database, err: %s\n", err.Error())
+		return err
+	}
+
+	switch subcommand {
+	case "list":
+		if user == nil {
+			sendResponse(w, formatSchedule(nil))
+			return nil
+		}
+		sendResponse(w, formatSchedule(user.Schedule))
+		return nil
+	case "add":
+		return t.add(ctx, api, command, w, user, keyFilters)
+	case "remove", "clear":
+		if user == nil || len(user.Schedule) == 0 {
+			sendResponse(w, "You have no schedule windows, you get updates at any time.")
+			return nil
+		}
+		windows := []*models.Schedule{}
+		if subcommand == "remove" {
+			index := 0
+			if len(args) > 1 {
+				index, _ = strconv.Atoi(args[1])
+			}
+			if index < 1 || index > len(user.Schedule) {
+				sendResponse(w, fmt.Sprintf("Pick a window to remove between 1 and %d, see `/track list`.", len(user.Schedule)))
+				return nil
+			}
+			windows = slices.Delete(slices.Clone(user.Schedule), index-1, index)
+		}
+
+		updates := mongo.Updates{
+			{
+				Key:            "schedule",
+				Value:          windows,
+				UpdateOperator: mongo.SET,
+			},
+		}
+		err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, keyFilters, updates, &user)
+		if err != nil {
+			log.Printf("[ERROR] failed to update user to database, err: %s\n", err.Error())
+			return err
+		}
+		sendResponse(w, formatSchedule(user.Schedule))
+		republishHome(api, command.UserID)
+		return nil
+	default:
+		sendResponse(w, trackUsage)
+		return nil
+	}
+}
+
+// add appends a schedule window, creating the user if they have none yet
+func (t *TrackCommand) add(ctx context.Context, api *slack.Client, command *slack.SlashCommand, w http.ResponseWriter, user *models.User, keyFilters mongo.Filters) error {
+	invalidTimeMsg := "`--from` and `--to` params must contain valid time like `--from=09:00` and `--to=17:00`, or `--from=22:00` and `--to=02:00` for a window past midnight"
 	if t.From == "" || t.To == "" {
 		sendResponse(w, invalidTimeMsg)
 		return nil
 	}
-	fromTime, err := time.Parse(shared.ScheduleTimeFormat, t.From)
-	if err != nil {
-		sendResponse(w, invalidTimeMsg)
+	window, err := schedule.Parse(t.From, t.To)
+	if err == schedule.ErrEmptyWindow {
+		sendResponse(w, "`--from` and `--to` must be different times")
 		return nil
 	}
-	toTime, err := time.Parse(shared.ScheduleTimeFormat, t.To)
 	if err != nil {
 		sendResponse(w, invalidTimeMsg)
 		return nil
 	}
 
-	if fromTime.After(toTime) {
-		sendResponse(w, "`--from` time must be before `--to` time")
-		return nil
+	if t.Days != "" {
+		window.Weekdays, err = schedule.ParseWeekdays(t.Days)
+		if err != nil {
+			sendResponse(w, "`--days` must list days like `--days=mon-fri` or `--days=sat,sun`")
+			return nil
+		}
 	}
-	fromStr := fromTime.Format(shared.ScheduleTimeFormat)
-	toStr := toTime.Format(shared.ScheduleTimeFormat)
-
-	newUser := false
-	var user *models.User
-	keyFilters := mongo.Filters{
-		{
-			Key:      "user_id",
-			Value:    command.UserID,
-			Operator: mongo.EQUAL,
-			Type:     mongo.STRING,
-		},
+	if t.Tz != "" {
+		if _, err := time.LoadLocation(t.Tz); err != nil {
+			sendResponse(w, "`--tz` must be a timezone like `--tz=Asia/Kolkata`")
+			return nil
+		}
+		window.Timezone = t.Tz
 	}
-	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, keyFilters, nil, &user)
-	if err == mongo.NoItemFound {
-		newUser = true
+
+	if user == nil {
 		user = &models.User{
 			UserId:     command.UserID,
 			ChannelId:  command.ChannelID,
 			TeamDomain: command.TeamDomain,
-			Schedule: []*models.Schedule{
-				{
-					From: fromStr,
-					To:   toStr,
-				},
-			},
+			Schedule:   []*models.Schedule{window},
 		}
 		err = env.MongoClient().Insert(ctx, env.MongoUsersCollectionName, user)
 		if err != nil {
 			log.Printf("[ERROR] failed to insert user to database, err: %s\n", err.Error())
 			return err
 		}
-	}
-	if err != nil {
-		log.Printf("[ERROR] failed to get user from database, err: %s\n", err.Error())
-		return err
-	}
+	} else {
+		// overlapping windows are merged, a window already covered changes nothing
+		merged, err := schedule.Normalise(append(slices.Clone(user.Schedule), window))
+		if err != nil {
+			log.Printf("[ERROR] failed to normalise schedule of %s, err: %s\n", command.UserID, err.Error())
+			sendResponse(w, "Your saved schedule has an invalid window, use `/track clear` and add your windows again.")
+			return nil
+		}
+		if slices.EqualFunc(merged, user.Schedule, (*models.Schedule).Equal) {
+			sendResponse(w, "You already track "+schedule.Format(window)+".\n\n"+formatSchedule(user.Schedule))
+			return nil
+		}
 
-	if !newUser {
 		updates := mongo.Updates{
 			{
 				Key:            "schedule",
-				Value:          []*models.Schedule{{From: fromStr, To: toStr}},
-				UpdateOperator: mongo.PUSH,
+				Value:          merged,
+				UpdateOperator: mongo.SET,
 			},
 		}
 		err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, keyFilters, updates, &user)
@@ -98,15 +180,24 @@ func (t *TrackCommand) Execute(ctx context.Context, api *slack.Client, command *
 		}
 	}
 
+	sendResponse(w, formatSchedule(user.Schedule))
+	republishHome(api, command.UserID)
+	return nil
+}
+
+// formatSchedule lists the schedule windows numbered for `/track remove`
+func formatSchedule(windows []*models.Schedule) string {
+	if len(windows) == 0 {
+		return "You have no schedule windows, you get updates at any time. Use `/track add --from=09:00 --to=17:00` to add one."
+	}
+
 	strBuilder := &strings.Builder{}
 	strBuilder.WriteString("We'll track your delivery orders: \n")
-	for _, schedule := range user.Schedule {
-		strBuilder.WriteString("From ")
-		strBuilder.WriteString(schedule.From)
-		strBuilder.WriteString(" — To ")
-		strBuilder.WriteString(schedule.To)
+	for i, s := range windows {
+		strBuilder.WriteString(strconv.Itoa(i + 1))
+		strBuilder.WriteString(". ")
+		strBuilder.WriteString(schedule.Format(s))
 		strBuilder.WriteString("\n")
 	}
-	sendResponse(w, strBuilder.String())
-	return nil
+	return strBuilder.String()
 }
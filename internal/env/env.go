@@ -2,6 +2,9 @@ package env
 
 import (
 	"context"
+	"log"
+	"testing"
+	"time"
 
 	"github.com/diabolusgx/snack-track/pkg/mongo"
 	"github.com/joho/godotenv"
@@ -18,10 +21,17 @@ const (
 	SlackSigningSecret = "SLACK_SIGNING_SECRET"
 	MongoConnectionURI = "MONGO_CONNECTION_URI"
 	SecretKey          = "SECRET_KEY"
+	OrderDedupWindow   = "ORDER_DEDUP_WINDOW"
+	EtaBreachMargin    = "ETA_BREACH_MARGIN"
+	StaleOrderTimeout  = "STALE_ORDER_TIMEOUT"
 
 	// global constants
-	MongoDatabaseName        = "snack-track"
-	MongoUsersCollectionName = "users"
+	MongoDatabaseName                = "snack-track"
+	MongoUsersCollectionName         = "users"
+	MongoOrdersCollectionName        = "orders"
+	MongoOrderKeysCollectionName     = "order_keys"
+	MongoLunchSessionsCollectionName = "lunch_sessions"
+	MongoLedgerCollectionName        = "ledger"
 )
 
 type Env struct {
@@ -34,7 +44,8 @@ var env *Env
 
 func init() {
 	vars, err := godotenv.Read(".env")
-	if err != nil {
+	// tests of packages depending on env run without a .env file
+	if err != nil && !testing.Testing() {
 		panic(err)
 	}
 	env = &Env{vars: vars}
@@ -64,3 +75,17 @@ func GetParam(key string) (string, bool) {
 	val, found := env.vars[key]
 	return val, found
 }
+
+// GetDurationParam returns the duration set for key, or fallback if it is unset or invalid
+func GetDurationParam(key string, fallback time.Duration) time.Duration {
+	val, found := env.vars[key]
+	if !found || val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Printf("[Env] Invalid duration %q for %s, using %s\n", val, key, fallback)
+		return fallback
+	}
+	return d
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/diabolusgx/snack-track/internal/env"
 	"github.com/diabolusgx/snack-track/internal/event"
+	"github.com/diabolusgx/snack-track/internal/home"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 )
@@ -81,6 +82,15 @@ func RegisterEventAPIHandler(api *slack.Client) {
 				ctx := context.Background()
 				event.Handle(ctx, api, ev)
 				return
+			case *slackevents.AppHomeOpenedEvent:
+				if ev.Tab != "home" {
+					return
+				}
+				ctx := context.Background()
+				if err := home.Publish(ctx, api, ev.User); err != nil {
+					fmt.Println("[SlackEventHandler] Failed to publish home view:", err)
+				}
+				return
 			}
 		}
 		fmt.Println("[INFO] Unhandled event:", eventsAPIEvent.Type)
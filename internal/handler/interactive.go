@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/interaction"
+	"github.com/slack-go/slack"
+)
+
+func RegisterInteractiveHandler(api *slack.Client) {
+	signingSecret, found := env.GetParam(env.SlackSigningSecret)
+	if !found {
+		panic("SLACK_SIGNING_SECRET is not set")
+	}
+
+	http.HandleFunc("/slack/interactive", func(w http.ResponseWriter, r *http.Request) {
+		// panic recovery
+		defer func() {
+			if r := recover(); r != nil {
+				debug.PrintStack()
+				log.Printf("[SlackInteractiveHandler] Recovered from panic: %v\n", r)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sv, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+		if err != nil {
+			fmt.Println("[SlackInteractiveHandler] Failed to create secrets verifier:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, err := sv.Write(body); err != nil {
+			fmt.Println("[SlackInteractiveHandler] Failed to write body:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err := sv.Ensure(); err != nil {
+			fmt.Println("[SlackInteractiveHandler] Failed to ensure signature:", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			fmt.Println("[SlackInteractiveHandler] Failed to parse body:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var callback slack.InteractionCallback
+		if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+			fmt.Println("[SlackInteractiveHandler] Failed to unmarshal payload:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.Background()
+		response, err := interaction.Handle(ctx, api, &callback)
+		if err != nil {
+			fmt.Println("[SlackInteractiveHandler] Failed to handle interaction:", callback.Type, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// view submissions can answer with validation errors or an updated view
+		if response != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(response); err != nil {
+				fmt.Println("[SlackInteractiveHandler] Failed to write response:", err)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	fmt.Println("[INFO] Interactive handler registered")
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +12,10 @@ import (
 
 	"github.com/diabolusgx/snack-track/internal/env"
 	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/internal/provider"
+	"github.com/diabolusgx/snack-track/internal/settings"
+	"github.com/diabolusgx/snack-track/internal/shared"
 	"github.com/diabolusgx/snack-track/internal/util"
 	"github.com/diabolusgx/snack-track/pkg/mongo"
 	"github.com/slack-go/slack"
@@ -19,11 +24,11 @@ import (
 func RegisterWebhookHandler(api *slack.Client) {
 	ctx := context.Background()
 
-	http.HandleFunc("/webhook/order-update", func(w http.ResponseWriter, r *http.Request) {
+	orderUpdateHandler := func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "POST")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 
 		// Handle preflight (OPTIONS) request
 		if r.Method == http.MethodOptions {
@@ -40,40 +45,8 @@ func RegisterWebhookHandler(api *slack.Client) {
 			}
 		}()
 
-		// TODO: validate if request is actually coming from SnackTrack browser extension.
-
-		buf, err := io.ReadAll(r.Body)
-		if err != nil {
-			log.Printf("[OrderUpdate] Failed to read request body: %v\n", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		// decode the request body into model.OrderUpdate
-		var orderUpdate *models.OrderUpdate
-		if err := json.Unmarshal(buf, &orderUpdate); err != nil {
-			log.Printf("[OrderUpdate] Failed to unmarshal request body: %v\n", err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		slackId := orderUpdate.SlackId
-		if slackId == "" {
-			log.Printf("[OrderUpdate] SlackId is empty\n")
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		// verify the slackId hash
-		userId, ok, err := util.GetSlackIdFromHash(slackId)
-		if err != nil {
-			log.Printf("[OrderUpdate] Failed to verify slackId hash: %v\n", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+		p, userId, o, ok := decodeOrderRequest(w, r, "OrderUpdate")
 		if !ok {
-			log.Printf("[OrderUpdate] SlackId hash verification failed\n")
-			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
@@ -86,31 +59,65 @@ func RegisterWebhookHandler(api *slack.Client) {
 				Operator: mongo.EQUAL,
 			},
 		}
-		err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+		err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
 		if err != nil {
 			log.Printf("[OrderUpdate] Failed to get user: %v\n", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		zOrder := orderUpdate.Order
-		msg := fmt.Sprintf(
-			"<@%s>'s order (`%d`) from %s is *%s* %s",
-			userId,
-			zOrder.OrderId,
-			zOrder.ResInfo.Name,
-			zOrder.DeliveryDetails.DeliveryLabel,
-			zOrder.DeliveryDetails.DeliveryMessage,
-		)
-		_, _, _, err = api.SendMessage(user.ChannelId, slack.MsgOptionText(msg, false))
+		outcome, err := order.Process(ctx, api, p, user, o, r.Header.Get("Idempotency-Key"))
 		if err != nil {
-			log.Printf("[OrderUpdate] Failed to send message: %v\n", err)
+			log.Printf("[OrderUpdate] Failed to process order %s: %v\n", o.OrderId, err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
-	})
+		if outcome == order.OutcomeDuplicate {
+			w.Write([]byte("duplicate"))
+		}
+	}
+	http.HandleFunc("/webhook/order-update", orderUpdateHandler)
+	http.HandleFunc("/webhook/{provider}/order-update", orderUpdateHandler)
+
+	// the extension reports orders whose state didn't change here, they only
+	// prove the order is still being tracked and are never posted
+	orderSeenHandler := func(w http.ResponseWriter, r *http.Request) {
+		// Set CORS headers
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+		// Handle preflight (OPTIONS) request
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// panic recovery
+		defer func() {
+			if r := recover(); r != nil {
+				debug.PrintStack()
+				log.Printf("[OrderSeen] Recovered from panic: %v\n", r)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+
+		_, userId, o, ok := decodeOrderRequest(w, r, "OrderSeen")
+		if !ok {
+			return
+		}
+
+		if err := order.Touch(ctx, userId, o); err != nil {
+			log.Printf("[OrderSeen] Failed to refresh last seen of order %s: %v\n", o.OrderId, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	http.HandleFunc("/webhook/order-seen", orderSeenHandler)
+	http.HandleFunc("/webhook/{provider}/order-seen", orderSeenHandler)
 
 	http.HandleFunc("/webhook/user-settings", func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
@@ -161,60 +168,20 @@ func RegisterWebhookHandler(api *slack.Client) {
 			return
 		}
 
-		if len(updateUserSettings.StartTime) != len(updateUserSettings.EndTime) {
-			log.Printf("[UserSettings] Invalid start/end time\n")
+		user, err := settings.Update(ctx, slackId, updateUserSettings)
+		var validationErr *settings.ValidationError
+		if errors.As(err, &validationErr) {
+			log.Printf("[UserSettings] Invalid settings: %v\n", err)
 			w.WriteHeader(http.StatusBadRequest)
-		}
-
-		// generate schedule
-		var schedule []*models.Schedule
-		for i := 0; i < len(updateUserSettings.StartTime); i++ {
-			schedule = append(schedule, &models.Schedule{
-				From: updateUserSettings.StartTime[i],
-				To:   updateUserSettings.EndTime[i],
-			})
-		}
-
-		var user *models.User
-		filters := mongo.Filters{
-			{
-				Key:      "user_id",
-				Value:    slackId,
-				Type:     mongo.STRING,
-				Operator: mongo.EQUAL,
-			},
-		}
-		updates := mongo.Updates{
-			{
-				Key:            "schedule",
-				Value:          schedule,
-				Type:           mongo.STRING,
-				UpdateOperator: mongo.SET,
-			},
-			{
-				Key:            "address_ids",
-				Value:          updateUserSettings.AddressIds,
-				Type:           mongo.STRING_ARRAY,
-				UpdateOperator: mongo.SET,
-			},
-		}
-		err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, updates, &user)
-		if err == mongo.NoItemFound {
-			err = env.MongoClient().Insert(ctx, env.MongoUsersCollectionName, &models.User{
-				UserId:     slackId,
-				Schedule:   schedule,
-				AddressIds: updateUserSettings.AddressIds,
-			})
+			return
 		}
 		if err != nil {
-			log.Printf("[UserSettings] Failed to get user: %v\n", err)
+			log.Printf("[UserSettings] Failed to update user: %v\n", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		msg := fmt.Sprintf("Your settings have been updated.\n\n%s", util.GetSlackMsgForSettings(user))
-		_, _, _, err = api.SendMessage(slackId, slack.MsgOptionText(msg, false))
-		if err != nil {
+		if err := settings.Announce(ctx, api, user); err != nil {
 			log.Printf("[UserSettings] Failed to send message: %v\n", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -225,3 +192,56 @@ func RegisterWebhookHandler(api *slack.Client) {
 
 	fmt.Println("[INFO] Webhook handler registered")
 }
+
+// decodeOrderRequest decodes an order posted by the extension and verifies the
+// sender's slack id. It writes the error response itself, ok is false then.
+func decodeOrderRequest(w http.ResponseWriter, r *http.Request, tag string) (provider.Provider, string, *models.Order, bool) {
+	// TODO: validate if request is actually coming from SnackTrack browser extension.
+
+	// the extension posts Zomato orders to the route without provider
+	providerName := r.PathValue("provider")
+	if providerName == "" {
+		providerName = shared.ProviderZomato
+	}
+	p, err := provider.NewProvider(providerName)
+	if err != nil {
+		log.Printf("[%s] %v\n", tag, err)
+		w.WriteHeader(http.StatusNotFound)
+		return nil, "", nil, false
+	}
+
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("[%s] Failed to read request body: %v\n", tag, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, "", nil, false
+	}
+
+	// decode the request body into the normalized order
+	slackId, o, err := p.Decode(buf)
+	if err != nil {
+		log.Printf("[%s] Failed to decode %s order: %v\n", tag, p.Name(), err)
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, "", nil, false
+	}
+
+	if slackId == "" {
+		log.Printf("[%s] SlackId is empty\n", tag)
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, "", nil, false
+	}
+
+	// verify the slackId hash
+	userId, ok, err := util.GetSlackIdFromHash(slackId)
+	if err != nil {
+		log.Printf("[%s] Failed to verify slackId hash: %v\n", tag, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, "", nil, false
+	}
+	if !ok {
+		log.Printf("[%s] SlackId hash verification failed\n", tag)
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, "", nil, false
+	}
+	return p, userId, o, true
+}
@@ -0,0 +1,190 @@
+package home
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// recentDeliveredLimit is how many delivered orders are listed on the Home tab
+const recentDeliveredLimit = 10
+
+// activeOrdersLimit is how many active orders are listed on the Home tab, views hold at most 100 blocks
+const activeOrdersLimit = 20
+
+// publishTimeout bounds a Home tab refresh running in the background
+const publishTimeout = 30 * time.Second
+
+// PublishAsync refreshes the user's Home tab in the background with its own
+// context, so handlers can answer Slack before the refresh is done.
+func PublishAsync(api *slack.Client, userId string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+		if err := Publish(ctx, api, userId); err != nil {
+			log.Printf("[Home] Failed to publish home view of %s: %v\n", userId, err)
+		}
+	}()
+}
+
+// Publish renders the user's App Home tab with their settings, active orders
+// and the most recently delivered ones.
+func Publish(ctx context.Context, api *slack.Client, userId string) error {
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err != nil && err != mongo.NoItemFound {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Snack Track", true, false)),
+	}
+	if user == nil {
+		blocks = append(blocks, markdown("You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started."))
+		return publish(ctx, api, userId, blocks)
+	}
+
+	settings, _ := card.Settings(user, "")
+	blocks = append(blocks, settings...)
+	if user.SnoozedUntil != nil && user.SnoozedUntil.After(time.Now()) {
+		blocks = append(blocks, markdown(fmt.Sprintf(":zzz: Order updates are snoozed until %s.", formatTime(*user.SnoozedUntil))))
+	}
+
+	activeFilter := mongo.Filter{
+		Key:      "active",
+		Value:    true,
+		Type:     mongo.BOOL,
+		Operator: mongo.EQUAL,
+	}
+	active, err := getOrders(ctx, userId, activeFilter, "updated_at", activeOrdersLimit)
+	if err != nil {
+		return err
+	}
+	blocks = append(blocks, slack.NewDividerBlock(), slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Active orders", true, false)))
+	if len(active) == 0 {
+		blocks = append(blocks, markdown("No orders on their way right now."))
+	}
+	for _, record := range active {
+		text := fmt.Sprintf("*%s* — %s\nOrder `%s`", restaurantName(record), statusLabel(record), record.OrderId)
+		if eta := record.Order.ExpectedDeliveryTime; eta != nil {
+			text += " · expected by " + formatTime(*eta)
+		}
+		blocks = append(blocks, markdown(text))
+	}
+	if len(active) == activeOrdersLimit {
+		total, err := env.MongoClient().Count(ctx, env.MongoOrdersCollectionName, orderFilters(userId, activeFilter))
+		if err != nil {
+			return fmt.Errorf("failed to count active orders: %w", err)
+		}
+		if more := total - len(active); more > 0 {
+			blocks = append(blocks, markdown(fmt.Sprintf("_…and %d more_", more)))
+		}
+	}
+
+	delivered, err := getOrders(ctx, userId, mongo.Filter{
+		Key:      "stage",
+		Value:    lifecycle.Delivered,
+		Type:     mongo.STRING,
+		Operator: mongo.EQUAL,
+	}, "delivered_at", recentDeliveredLimit)
+	if err != nil {
+		return err
+	}
+	blocks = append(blocks, slack.NewDividerBlock(), slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Recently delivered", true, false)))
+	if len(delivered) == 0 {
+		blocks = append(blocks, markdown("Nothing delivered yet."))
+	}
+	for _, record := range delivered {
+		text := fmt.Sprintf("*%s* · `%s`", restaurantName(record), record.OrderId)
+		if record.DeliveredAt != nil {
+			text += " · " + formatTime(*record.DeliveredAt)
+		}
+		if bill := record.Order.Bill; bill != nil && bill.Total > 0 {
+			text += " · " + util.FormatAmount(bill.Total, bill.Currency)
+		}
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, text, false, false)))
+	}
+
+	return publish(ctx, api, userId, blocks)
+}
+
+func publish(ctx context.Context, api *slack.Client, userId string, blocks []slack.Block) error {
+	view := slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}
+	if _, err := api.PublishViewContext(ctx, userId, view, ""); err != nil {
+		return fmt.Errorf("failed to publish home view: %w", err)
+	}
+	return nil
+}
+
+// getOrders returns the user's orders matching filter, newest first by sortKey
+func getOrders(ctx context.Context, userId string, filter mongo.Filter, sortKey string, limit int64) ([]*models.OrderRecord, error) {
+	var records []*models.OrderRecord
+	sortKeys := []mongo.SortKey{{Key: sortKey, Order: mongo.DSC}}
+	_, err := env.MongoClient().GetSorted(ctx, env.MongoOrdersCollectionName, orderFilters(userId, filter), "", limit, sortKeys, &records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	// orders stored without a snapshot can't be rendered
+	result := records[:0]
+	for _, record := range records {
+		if record.Order != nil {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+func orderFilters(userId string, filter mongo.Filter) mongo.Filters {
+	return mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		filter,
+	}
+}
+
+func markdown(text string) slack.Block {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
+
+func formatTime(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", t.Unix(), t.UTC().Format(time.Kitchen))
+}
+
+func restaurantName(record *models.OrderRecord) string {
+	if record.Order.RestaurantName == "" {
+		return "Your order"
+	}
+	return record.Order.RestaurantName
+}
+
+func statusLabel(record *models.OrderRecord) string {
+	if record.Order.DeliveryLabel != "" {
+		return record.Order.DeliveryLabel
+	}
+	return record.Stage.Label()
+}
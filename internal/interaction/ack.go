@@ -0,0 +1,35 @@
+package interaction
+
+import (
+	"context"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+func handleOrderAck(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback, action *slack.BlockAction) error {
+	// the value is "<provider>:<user id>:<order id>"
+	parts := strings.SplitN(action.Value, ":", 3)
+	if len(parts) != 3 {
+		return respond(ctx, api, callback, "This button is outdated, the order can no longer be acknowledged from it.")
+	}
+
+	record, err := order.Get(ctx, parts[0], parts[1], parts[2])
+	if err == mongo.NoItemFound {
+		return respond(ctx, api, callback, "This order no longer exists.")
+	}
+	if err != nil {
+		return err
+	}
+
+	ok, err := order.Acknowledge(ctx, api, record, callback.User.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return respond(ctx, api, callback, "Someone already picked this order up.")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package interaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/slack-go/slack"
+)
+
+// ActionHandler handles a block action, e.g. a button click, keyed by action_id
+type ActionHandler func(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback, action *slack.BlockAction) error
+
+// ViewHandler handles a modal submission, keyed by the view's callback_id. A
+// non nil response is sent back to Slack, e.g. to show validation errors.
+type ViewHandler func(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback) (*slack.ViewSubmissionResponse, error)
+
+// ShortcutHandler handles a global or message shortcut, keyed by callback_id
+type ShortcutHandler func(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback) error
+
+var actionHandlers = map[string]ActionHandler{
+	shared.ActionOrderAck:     handleOrderAck,
+	shared.ActionLunchJoin:    handleLunchAction,
+	shared.ActionLunchLeave:   handleLunchAction,
+	shared.ActionLunchVote:    handleLunchAction,
+	shared.ActionLunchClose:   handleLunchAction,
+	shared.ActionSettingsEdit: handleSettingsEdit,
+}
+
+var viewHandlers = map[string]ViewHandler{
+	shared.ViewSettings: handleSettingsSubmit,
+}
+
+var shortcutHandlers = map[string]ShortcutHandler{
+	shared.ShortcutSettings: handleSettingsShortcut,
+}
+
+// Handle routes an interaction payload to the handler registered for its
+// action_id or callback_id. The returned value, if any, is the response body.
+func Handle(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback) (interface{}, error) {
+	switch callback.Type {
+	case slack.InteractionTypeBlockActions:
+		for _, action := range callback.ActionCallback.BlockActions {
+			handler, ok := actionHandlers[action.ActionID]
+			if !ok {
+				fmt.Println("[INFO] Unhandled block action:", action.ActionID)
+				continue
+			}
+			if err := handler(ctx, api, callback, action); err != nil {
+				return nil, fmt.Errorf("failed to handle %s: %w", action.ActionID, err)
+			}
+		}
+		return nil, nil
+	case slack.InteractionTypeViewSubmission:
+		handler, ok := viewHandlers[callback.View.CallbackID]
+		if !ok {
+			fmt.Println("[INFO] Unhandled view submission:", callback.View.CallbackID)
+			return nil, nil
+		}
+		response, err := handler(ctx, api, callback)
+		if err != nil {
+			return nil, fmt.Errorf("failed to handle %s: %w", callback.View.CallbackID, err)
+		}
+		if response == nil {
+			return nil, nil
+		}
+		return response, nil
+	case slack.InteractionTypeShortcut, slack.InteractionTypeMessageAction:
+		handler, ok := shortcutHandlers[callback.CallbackID]
+		if !ok {
+			fmt.Println("[INFO] Unhandled shortcut:", callback.CallbackID)
+			return nil, nil
+		}
+		if err := handler(ctx, api, callback); err != nil {
+			return nil, fmt.Errorf("failed to handle %s: %w", callback.CallbackID, err)
+		}
+		return nil, nil
+	default:
+		fmt.Println("[INFO] Unhandled interaction:", callback.Type)
+		return nil, nil
+	}
+}
+
+// respond shows text only to the user who triggered the interaction
+func respond(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback, text string) error {
+	// actions on the App Home tab have no channel, the reply goes to the user's DM instead
+	if callback.Channel.ID == "" {
+		_, _, err := api.PostMessageContext(ctx, callback.User.ID, slack.MsgOptionText(text, false))
+		return err
+	}
+	_, err := api.PostEphemeralContext(ctx, callback.Channel.ID, callback.User.ID, slack.MsgOptionText(text, false))
+	return err
+}
@@ -0,0 +1,61 @@
+package interaction
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/lunch"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+func handleLunchAction(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback, action *slack.BlockAction) error {
+	// vote values are "<session id>:<option index>", the other buttons carry the session id
+	sessionId, option, _ := strings.Cut(action.Value, ":")
+
+	session, err := lunch.Get(ctx, sessionId)
+	if err == mongo.NoItemFound {
+		return respond(ctx, api, callback, "This lunch session no longer exists.")
+	}
+	if err != nil {
+		return err
+	}
+	if session.Status != models.LunchStatusOpen {
+		return respond(ctx, api, callback, "This lunch session is already closed.")
+	}
+
+	userId := callback.User.ID
+	switch action.ActionID {
+	case shared.ActionLunchJoin:
+		_, err = lunch.Join(ctx, api, session, userId)
+	case shared.ActionLunchLeave:
+		if userId == session.OrganiserId {
+			return respond(ctx, api, callback, "You are organising this lunch, close it instead.")
+		}
+		_, err = lunch.Leave(ctx, api, session, userId)
+	case shared.ActionLunchVote:
+		index, err := strconv.Atoi(option)
+		if err != nil {
+			return fmt.Errorf("invalid vote %q: %w", action.Value, err)
+		}
+		_, err = lunch.Vote(ctx, api, session, userId, index)
+		if err == mongo.NoItemFound {
+			return respond(ctx, api, callback, "This lunch session is already closed.")
+		}
+		return err
+	case shared.ActionLunchClose:
+		if userId != session.OrganiserId {
+			return respond(ctx, api, callback, "Only <@"+session.OrganiserId+"> can close this lunch session.")
+		}
+		return lunch.Close(ctx, api, session)
+	}
+	if err == mongo.NoItemFound {
+		// closed between reading the session and updating it
+		return respond(ctx, api, callback, "This lunch session is already closed.")
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package interaction
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/settings"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// handleSettingsShortcut sends the user their settings by DM, shortcuts have no channel to respond in
+func handleSettingsShortcut(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback) error {
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    callback.User.ID,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	text := ""
+	err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	switch {
+	case err == mongo.NoItemFound:
+		text = "You have not set up your SnackTrack settings yet.\nPlease use `/st-channel`, `/st-token` and Snack Track extension to get started."
+	case err != nil:
+		return err
+	default:
+		blocks, text := card.Settings(user, "")
+		_, _, err = api.PostMessageContext(ctx, callback.User.ID, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+		return err
+	}
+
+	_, _, err = api.PostMessageContext(ctx, callback.User.ID, slack.MsgOptionText(text, false))
+	return err
+}
+
+// handleSettingsEdit opens the settings modal from the button on settings messages and the Home tab
+func handleSettingsEdit(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback, action *slack.BlockAction) error {
+	return settings.OpenModal(ctx, api, callback.TriggerID, callback.User.ID)
+}
+
+// handleSettingsSubmit saves the settings modal, showing validation errors next to the offending inputs
+func handleSettingsSubmit(ctx context.Context, api *slack.Client, callback *slack.InteractionCallback) (*slack.ViewSubmissionResponse, error) {
+	user, err := settings.Update(ctx, callback.User.ID, settings.ParseModal(callback.View))
+	var validationErr *settings.ValidationError
+	if errors.As(err, &validationErr) {
+		return slack.NewErrorsViewSubmissionResponse(settings.ModalErrors(callback.View, validationErr)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := settings.Announce(ctx, api, user); err != nil {
+		log.Printf("[Settings] Failed to send settings to %s: %v\n", user.UserId, err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,114 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// reminderWindow bounds how long after delivery an order is still reminded of
+const reminderWindow = 3 * time.Hour
+
+// DeliveryReminders re-pings users, and their backup teammate, about delivered
+// orders nobody acknowledged with the "I've got it" button
+type DeliveryReminders struct {
+}
+
+func (d *DeliveryReminders) Name() string {
+	return "DeliveryReminders"
+}
+
+func (d *DeliveryReminders) Interval() time.Duration {
+	return time.Minute
+}
+
+func (d *DeliveryReminders) Run(ctx context.Context, api *slack.Client) error {
+	now := time.Now()
+
+	var records []*models.OrderRecord
+	filters := mongo.Filters{
+		{
+			Key:      "stage",
+			Value:    lifecycle.Delivered,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "acked_at",
+			Value:    nil,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "delivered_at",
+			Value:    now.Add(-reminderWindow),
+			Type:     mongo.TIME,
+			Operator: mongo.GREATER_THAN_EQUAL,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoOrdersCollectionName, filters, "", 0, &records)
+	if err != nil {
+		return fmt.Errorf("failed to get unacknowledged orders: %w", err)
+	}
+
+	for _, record := range records {
+		// orders that were never posted have no button to press
+		if len(record.Messages) == 0 {
+			continue
+		}
+
+		user, err := getUser(ctx, record.UserId)
+		if err != nil {
+			log.Printf("[DeliveryReminders] Failed to get user %s for order %s: %v\n", record.UserId, record.OrderId, err)
+			continue
+		}
+		interval, limit := user.ReminderSettings()
+		if user.RemindersOff || record.Reminders >= limit {
+			continue
+		}
+		last := *record.DeliveredAt
+		if record.LastRemindedAt != nil {
+			last = *record.LastRemindedAt
+		}
+		if now.Sub(last) < interval {
+			continue
+		}
+
+		// reminders follow the user's filters like order updates do, skipped ones are not counted
+		if order.Filtered(ctx, api, user, record, now) != "" {
+			continue
+		}
+		if !order.BreaksThrough(user, record.Stage) && order.IsQuiet(ctx, api, user, now) {
+			continue
+		}
+
+		text := fmt.Sprintf(
+			":stew: <@%s>, your order from %s was delivered %d min ago and nobody has picked it up yet, the food is getting cold! Press *I've got it* once you have it.",
+			user.UserId,
+			restaurantName(record),
+			int(now.Sub(*record.DeliveredAt).Minutes()),
+		)
+		if user.ReminderBackup != "" {
+			text += fmt.Sprintf(" cc <@%s>", user.ReminderBackup)
+		}
+		posted, err := order.PostFollowUp(ctx, api, user, record, text)
+		if err != nil {
+			log.Printf("[DeliveryReminders] Failed to post reminder for order %s: %v\n", record.OrderId, err)
+			continue
+		}
+		if !posted {
+			continue
+		}
+		if err := order.MarkReminded(ctx, record, now); err != nil {
+			log.Printf("[DeliveryReminders] Failed to count reminder for order %s: %v\n", record.OrderId, err)
+		}
+	}
+	return nil
+}
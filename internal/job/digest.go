@@ -0,0 +1,126 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/stats"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// digestHour is the hour of the day, in the user's timezone, digests are posted at
+const digestHour = 9
+
+// Digest posts weekly and monthly order summaries to users who opted in.
+// Weekly digests cover the previous Monday to Sunday, monthly ones the previous
+// calendar month, both in the user's timezone.
+type Digest struct {
+}
+
+func (d *Digest) Name() string {
+	return "Digest"
+}
+
+func (d *Digest) Interval() time.Duration {
+	return 15 * time.Minute
+}
+
+func (d *Digest) Run(ctx context.Context, api *slack.Client) error {
+	var users []*models.User
+	filters := mongo.Filters{
+		{
+			Key:      "digests",
+			Value:    []string{shared.DigestWeekly, shared.DigestMonthly},
+			Operator: mongo.IN,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoUsersCollectionName, filters, "", 0, &users)
+	if err != nil {
+		return fmt.Errorf("failed to get digest subscribers: %w", err)
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		loc := util.GetUserLocation(ctx, api, user)
+		for _, digest := range user.Digests {
+			from, to, ok := digestPeriod(digest, now.In(loc))
+			if !ok || user.DigestsSentAt[digest].After(to) {
+				continue
+			}
+			if err := sendDigest(ctx, api, user, digest, from, to, loc); err != nil {
+				log.Printf("[Digest] Failed to send %s digest to %s: %v\n", digest, user.UserId, err)
+			}
+		}
+	}
+	return nil
+}
+
+// digestPeriod returns the period the latest digest of the given type covers,
+// ok is false until the digest is due.
+func digestPeriod(digest string, now time.Time) (time.Time, time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var from, to time.Time
+	switch digest {
+	case shared.DigestWeekly:
+		// weeks start on Monday
+		to = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		from = to.AddDate(0, 0, -7)
+	case shared.DigestMonthly:
+		to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		from = to.AddDate(0, -1, 0)
+	default:
+		return from, to, false
+	}
+	return from, to, !now.Before(to.Add(digestHour * time.Hour))
+}
+
+func sendDigest(ctx context.Context, api *slack.Client, user *models.User, digest string, from, to time.Time, loc *time.Location) error {
+	summary, err := stats.ForUser(ctx, user.UserId, from, to, loc)
+	if err != nil {
+		return err
+	}
+
+	// nothing worth posting, the period is still marked as sent
+	if summary.Orders > 0 {
+		channelId := util.GetUpdatesChannel(user)
+		if channelId == "" || user.DigestDelivery == shared.DigestDeliveryDM {
+			channelId = user.UserId
+		}
+		text := fmt.Sprintf(
+			":bar_chart: *Your %s Snack Track digest* (%s – %s)\n%s",
+			digest,
+			from.Format("Jan 2"),
+			to.AddDate(0, 0, -1).Format("Jan 2"),
+			stats.Format(summary),
+		)
+		if _, _, err := api.PostMessageContext(ctx, channelId, slack.MsgOptionText(text, false)); err != nil {
+			return fmt.Errorf("failed to post digest: %w", err)
+		}
+	}
+
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    user.UserId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	updates := mongo.Updates{
+		{
+			Key:            "digests_sent_at." + digest,
+			Value:          time.Now(),
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoUsersCollectionName, filters, updates)
+}
@@ -0,0 +1,116 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// defaultEtaBreachMargin is used when ETA_BREACH_MARGIN is not set
+const defaultEtaBreachMargin = 10 * time.Minute
+
+// EtaBreach escalates active orders that are late by more than the configured margin
+type EtaBreach struct {
+}
+
+func (e *EtaBreach) Name() string {
+	return "EtaBreach"
+}
+
+func (e *EtaBreach) Interval() time.Duration {
+	return time.Minute
+}
+
+func (e *EtaBreach) Run(ctx context.Context, api *slack.Client) error {
+	now := time.Now()
+	margin := env.GetDurationParam(env.EtaBreachMargin, defaultEtaBreachMargin)
+
+	var records []*models.OrderRecord
+	filters := mongo.Filters{
+		{
+			Key:      "active",
+			Value:    true,
+			Type:     mongo.BOOL,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "late",
+			Value:    []bool{true},
+			Operator: mongo.NOT_IN,
+		},
+		{
+			Key:      "order.expected_delivery_time",
+			Value:    now.Add(-margin),
+			Type:     mongo.TIME,
+			Operator: mongo.LESS_THAN,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoOrdersCollectionName, filters, "", 0, &records)
+	if err != nil {
+		return fmt.Errorf("failed to get late orders: %w", err)
+	}
+
+	for _, record := range records {
+		user, err := getUser(ctx, record.UserId)
+		if err != nil {
+			log.Printf("[EtaBreach] Failed to get user %s for order %s: %v\n", record.UserId, record.OrderId, err)
+			continue
+		}
+		// orders filtered out of updates are never escalated, snoozed users get the alert once the snooze ends
+		if order.Suppressed(ctx, api, user, record, now) != "" {
+			continue
+		}
+
+		expected := *record.Order.ExpectedDeliveryTime
+		text := fmt.Sprintf(
+			":rotating_light: <@%s>, your order (`%s`) from %s was expected by <!date^%d^{time}|%s> and is running %d min late. It is still *%s*.",
+			user.UserId,
+			record.OrderId,
+			restaurantName(record),
+			expected.Unix(),
+			expected.UTC().Format(time.Kitchen),
+			int(now.Sub(expected).Minutes()),
+			record.Stage.Label(),
+		)
+		posted, err := order.PostFollowUp(ctx, api, user, record, text)
+		if err != nil {
+			log.Printf("[EtaBreach] Failed to post alert for order %s: %v\n", record.OrderId, err)
+			continue
+		}
+		if !posted {
+			log.Printf("[EtaBreach] Not alerting about order %s of %s, it was not posted to any destination wanting it\n", record.OrderId, user.UserId)
+		}
+		if err := order.MarkEtaAlerted(ctx, record, now); err != nil {
+			log.Printf("[EtaBreach] Failed to mark order %s as alerted: %v\n", record.OrderId, err)
+		}
+	}
+	return nil
+}
+
+func getUser(ctx context.Context, userId string) (*models.User, error) {
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	return user, err
+}
+
+func restaurantName(record *models.OrderRecord) string {
+	if record.Order == nil || record.Order.RestaurantName == "" {
+		return "the restaurant"
+	}
+	return record.Order.RestaurantName
+}
@@ -0,0 +1,54 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Job is a task run periodically in the background
+type Job interface {
+	Name() string
+	Interval() time.Duration
+	Run(ctx context.Context, api *slack.Client) error
+}
+
+// Start runs every job on its own ticker until ctx is cancelled
+func Start(ctx context.Context, api *slack.Client, jobs ...Job) {
+	for _, j := range jobs {
+		go schedule(ctx, api, j)
+	}
+	fmt.Println("[INFO] Background jobs started")
+}
+
+func schedule(ctx context.Context, api *slack.Client, j Job) {
+	ticker := time.NewTicker(j.Interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run(ctx, api, j)
+		}
+	}
+}
+
+func run(ctx context.Context, api *slack.Client, j Job) {
+	// panic recovery
+	defer func() {
+		if r := recover(); r != nil {
+			debug.PrintStack()
+			log.Printf("[%s] Recovered from panic: %v\n", j.Name(), r)
+		}
+	}()
+
+	if err := j.Run(ctx, api); err != nil {
+		log.Printf("[%s] Failed to run job: %v\n", j.Name(), err)
+	}
+}
@@ -0,0 +1,55 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lunch"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// LunchCutoff closes open lunch sessions once their cutoff has passed
+type LunchCutoff struct {
+}
+
+func (l *LunchCutoff) Name() string {
+	return "LunchCutoff"
+}
+
+func (l *LunchCutoff) Interval() time.Duration {
+	return time.Minute
+}
+
+func (l *LunchCutoff) Run(ctx context.Context, api *slack.Client) error {
+	var sessions []*models.LunchSession
+	filters := mongo.Filters{
+		{
+			Key:      "status",
+			Value:    models.LunchStatusOpen,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "cutoff",
+			Value:    time.Now(),
+			Type:     mongo.TIME,
+			Operator: mongo.LESS_THAN_EQUAL,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoLunchSessionsCollectionName, filters, "", 0, &sessions)
+	if err != nil {
+		return fmt.Errorf("failed to get lunch sessions past cutoff: %w", err)
+	}
+
+	for _, session := range sessions {
+		if err := lunch.Close(ctx, api, session); err != nil {
+			log.Printf("[LunchCutoff] Failed to close lunch session %s: %v\n", session.SessionId, err)
+		}
+	}
+	return nil
+}
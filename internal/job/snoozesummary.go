@@ -0,0 +1,113 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// SnoozeSummary DMs a summary of the updates held back while a user was snoozed
+// or in Do Not Disturb once they are no longer, and brings the cards of those
+// orders up to date.
+type SnoozeSummary struct {
+}
+
+func (s *SnoozeSummary) Name() string {
+	return "SnoozeSummary"
+}
+
+func (s *SnoozeSummary) Interval() time.Duration {
+	return time.Minute
+}
+
+func (s *SnoozeSummary) Run(ctx context.Context, api *slack.Client) error {
+	var users []*models.User
+	filters := mongo.Filters{
+		{
+			Key:      "held_updates",
+			Value:    []interface{}{nil, []interface{}{}},
+			Operator: mongo.NOT_IN,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoUsersCollectionName, filters, "", 0, &users)
+	if err != nil {
+		return fmt.Errorf("failed to get users with held updates: %w", err)
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		if len(user.HeldUpdates) == 0 || order.IsQuiet(ctx, api, user, now) {
+			continue
+		}
+
+		refreshCards(ctx, api, user)
+
+		// the summary is personal, shared channels only get the refreshed cards
+		if _, _, err := api.PostMessageContext(ctx, user.UserId, slack.MsgOptionText(summarise(user.HeldUpdates), false)); err != nil {
+			log.Printf("[SnoozeSummary] Failed to DM summary to %s: %v\n", user.UserId, err)
+			continue
+		}
+
+		last := user.HeldUpdates[len(user.HeldUpdates)-1]
+		if err := order.ClearHeld(ctx, user.UserId, last.At); err != nil {
+			log.Printf("[SnoozeSummary] Failed to clear held updates of %s: %v\n", user.UserId, err)
+		}
+	}
+	return nil
+}
+
+// refreshCards updates the cards of every order with held updates once
+func refreshCards(ctx context.Context, api *slack.Client, user *models.User) {
+	refreshed := map[string]bool{}
+	for _, update := range user.HeldUpdates {
+		key := update.Provider + ":" + update.OrderId
+		if refreshed[key] {
+			continue
+		}
+		refreshed[key] = true
+
+		record, err := order.Get(ctx, update.Provider, user.UserId, update.OrderId)
+		if err != nil {
+			log.Printf("[SnoozeSummary] Failed to get order %s of %s: %v\n", update.OrderId, user.UserId, err)
+			continue
+		}
+		order.RefreshCards(ctx, api, user, record)
+	}
+}
+
+// summarise lists the latest held update of every order, in the order they were first held
+func summarise(held []*models.HeldUpdate) string {
+	var keys []string
+	latest := map[string]*models.HeldUpdate{}
+	for _, update := range held {
+		key := update.Provider + ":" + update.OrderId
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = update
+	}
+
+	strBuilder := &strings.Builder{}
+	strBuilder.WriteString(":bell: Here's what happened to your orders while you were away:\n")
+	for _, key := range keys {
+		update := latest[key]
+		restaurant := update.RestaurantName
+		if restaurant == "" {
+			restaurant = "the restaurant"
+		}
+		label := update.Label
+		if label == "" {
+			label = update.Stage.Label()
+		}
+		strBuilder.WriteString(fmt.Sprintf("- Order (`%s`) from %s is *%s*\n", update.OrderId, restaurant, label))
+	}
+	return strBuilder.String()
+}
@@ -0,0 +1,98 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// defaultStaleOrderTimeout is used when STALE_ORDER_TIMEOUT is not set
+const defaultStaleOrderTimeout = 30 * time.Minute
+
+// StaleOrders expires active orders the extension stopped reporting, e.g. because
+// the browser was closed, so their last status doesn't look live forever.
+type StaleOrders struct {
+}
+
+func (s *StaleOrders) Name() string {
+	return "StaleOrders"
+}
+
+func (s *StaleOrders) Interval() time.Duration {
+	return time.Minute
+}
+
+func (s *StaleOrders) Run(ctx context.Context, api *slack.Client) error {
+	timeout := env.GetDurationParam(env.StaleOrderTimeout, defaultStaleOrderTimeout)
+
+	var records []*models.OrderRecord
+	filters := mongo.Filters{
+		{
+			Key:      "active",
+			Value:    true,
+			Type:     mongo.BOOL,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "last_seen_at",
+			Value:    time.Now().Add(-timeout),
+			Type:     mongo.TIME,
+			Operator: mongo.LESS_THAN,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoOrdersCollectionName, filters, "", 0, &records)
+	if err != nil {
+		return fmt.Errorf("failed to get stale orders: %w", err)
+	}
+
+	for _, record := range records {
+		log.Printf("[StaleOrders] Order %s of %s not reported since %s, tracking lost\n", record.OrderId, record.UserId, record.LastSeenAt)
+
+		user, err := getUser(ctx, record.UserId)
+		if err != nil {
+			log.Printf("[StaleOrders] Failed to get user %s for order %s: %v\n", record.UserId, record.OrderId, err)
+			continue
+		}
+
+		blocks, text := card.Order(record.UserId, record.Order, record.Stage, record.LastSeenAt, user.ShowItems)
+		blocks = append(blocks, card.Notice("Tracking lost, this order is no longer being updated."))
+		for _, msg := range record.Messages {
+			_, _, _, err := api.UpdateMessageContext(ctx, msg.ChannelId, msg.Ts, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+			if err != nil {
+				log.Printf("[StaleOrders] Failed to update message of order %s in %s: %v\n", record.OrderId, msg.ChannelId, err)
+			}
+		}
+
+		// the DM follows the user's filters like order updates do, the card above is marked either way
+		if reason := order.Suppressed(ctx, api, user, record, time.Now()); reason != "" {
+			log.Printf("[StaleOrders] Not sending DM about order %s of %s: %s\n", record.OrderId, record.UserId, reason)
+		} else if err := notifyTrackingLost(ctx, api, record, timeout); err != nil {
+			log.Printf("[StaleOrders] Failed to DM %s about order %s: %v\n", record.UserId, record.OrderId, err)
+		}
+
+		if err := order.MarkTrackingLost(ctx, record); err != nil {
+			log.Printf("[StaleOrders] Failed to expire order %s: %v\n", record.OrderId, err)
+		}
+	}
+	return nil
+}
+
+// notifyTrackingLost DMs the user that their order is no longer tracked
+func notifyTrackingLost(ctx context.Context, api *slack.Client, record *models.OrderRecord, timeout time.Duration) error {
+	dm := fmt.Sprintf(
+		"We haven't heard about your order (`%s`) from %s for %d minutes, so it's no longer tracked. Please reopen your browser with the *Snack Track* extension enabled to resume updates.",
+		record.OrderId,
+		restaurantName(record),
+		int(timeout.Minutes()),
+	)
+	_, _, err := api.PostMessageContext(ctx, record.UserId, slack.MsgOptionText(dm, false))
+	return err
+}
@@ -0,0 +1,120 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Stage is a provider independent step in an order's lifecycle
+type Stage string
+
+const (
+	Placed         Stage = "placed"
+	Accepted       Stage = "accepted"
+	Preparing      Stage = "preparing"
+	PickedUp       Stage = "picked_up"
+	OutForDelivery Stage = "out_for_delivery"
+	Delivered      Stage = "delivered"
+	Cancelled      Stage = "cancelled"
+	Failed         Stage = "failed"
+	Unknown        Stage = "unknown"
+)
+
+var (
+	// ErrUnknownStatus is returned for provider status codes without a known stage
+	ErrUnknownStatus = errors.New("unknown order status")
+	// ErrRegression is returned when an order moves back to an earlier stage
+	ErrRegression = errors.New("order stage regressed")
+	// ErrTerminal is returned when an order leaves a terminal stage
+	ErrTerminal = errors.New("order already reached a terminal stage")
+)
+
+// Progress lists the stages of a successful order, in order
+var Progress = []Stage{Placed, Accepted, Preparing, PickedUp, OutForDelivery, Delivered}
+
+// Stages lists every known stage
+var Stages = []Stage{Placed, Accepted, Preparing, PickedUp, OutForDelivery, Delivered, Cancelled, Failed}
+
+var labels = map[Stage]string{
+	Placed:         "Placed",
+	Accepted:       "Accepted",
+	Preparing:      "Preparing",
+	PickedUp:       "Picked up",
+	OutForDelivery: "Out for delivery",
+	Delivered:      "Delivered",
+	Cancelled:      "Cancelled",
+	Failed:         "Failed",
+	Unknown:        "Unknown",
+}
+
+// Parse returns the stage named s
+func Parse(s string) (Stage, error) {
+	stage := Stage(s)
+	if _, ok := labels[stage]; !ok || stage == Unknown {
+		return Unknown, fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+	}
+	return stage, nil
+}
+
+// ParseAll returns the stages named in names, failing on the first unknown one
+func ParseAll(names []string) ([]Stage, error) {
+	stages := make([]Stage, 0, len(names))
+	for _, name := range names {
+		stage, err := Parse(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// Transition checks whether an order may move from one stage to another.
+// An empty from stage is a new order. Skipping stages forward is allowed,
+// cancelled and failed can be reached from every active stage.
+func Transition(from, to Stage) error {
+	if to == Unknown {
+		return ErrUnknownStatus
+	}
+	if from == "" || from == Unknown || from == to {
+		return nil
+	}
+	if from.IsTerminal() {
+		return fmt.Errorf("%w: %s to %s", ErrTerminal, from, to)
+	}
+	if to == Cancelled || to == Failed {
+		return nil
+	}
+	if to.rank() < from.rank() {
+		return fmt.Errorf("%w: %s to %s", ErrRegression, from, to)
+	}
+	return nil
+}
+
+// IsTerminal reports whether no further updates are expected for the stage
+func (s Stage) IsTerminal() bool {
+	return s == Delivered || s == Cancelled || s == Failed
+}
+
+// IsActive reports whether the order is still in progress
+func (s Stage) IsActive() bool {
+	return s != Unknown && s != "" && !s.IsTerminal()
+}
+
+// Label is the human readable name of the stage
+func (s Stage) Label() string {
+	if label, ok := labels[s]; ok {
+		return label
+	}
+	return labels[Unknown]
+}
+
+func (s Stage) rank() int {
+	for i, stage := range Progress {
+		if stage == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,70 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Stage
+		to      Stage
+		wantErr error
+	}{
+		{name: "new order", from: "", to: Placed},
+		{name: "new order in a later stage", from: "", to: OutForDelivery},
+		{name: "from unknown", from: Unknown, to: Preparing},
+		{name: "same stage", from: Preparing, to: Preparing},
+		{name: "next stage", from: Placed, to: Accepted},
+		{name: "skipping stages", from: Accepted, to: Delivered},
+		{name: "cancelled while active", from: Preparing, to: Cancelled},
+		{name: "failed while active", from: OutForDelivery, to: Failed},
+		{name: "back to an earlier stage", from: OutForDelivery, to: Preparing, wantErr: ErrRegression},
+		{name: "back to placed", from: Accepted, to: Placed, wantErr: ErrRegression},
+		{name: "to unknown", from: Placed, to: Unknown, wantErr: ErrUnknownStatus},
+		{name: "new order to unknown", from: "", to: Unknown, wantErr: ErrUnknownStatus},
+		{name: "delivered is terminal", from: Delivered, to: OutForDelivery, wantErr: ErrTerminal},
+		{name: "delivered can't be cancelled", from: Delivered, to: Cancelled, wantErr: ErrTerminal},
+		{name: "cancelled is terminal", from: Cancelled, to: Placed, wantErr: ErrTerminal},
+		{name: "failed is terminal", from: Failed, to: Delivered, wantErr: ErrTerminal},
+		{name: "terminal stage repeated", from: Delivered, to: Delivered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Transition(tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Errorf("Transition(%q, %q) = %v, want %v", tt.from, tt.to, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStage(t *testing.T) {
+	tests := []struct {
+		stage        Stage
+		wantTerminal bool
+		wantActive   bool
+	}{
+		{stage: Placed, wantActive: true},
+		{stage: Accepted, wantActive: true},
+		{stage: Preparing, wantActive: true},
+		{stage: PickedUp, wantActive: true},
+		{stage: OutForDelivery, wantActive: true},
+		{stage: Delivered, wantTerminal: true},
+		{stage: Cancelled, wantTerminal: true},
+		{stage: Failed, wantTerminal: true},
+		{stage: Unknown},
+		{stage: ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.stage), func(t *testing.T) {
+			if got := tt.stage.IsTerminal(); got != tt.wantTerminal {
+				t.Errorf("%q.IsTerminal() = %v, want %v", tt.stage, got, tt.wantTerminal)
+			}
+			if got := tt.stage.IsActive(); got != tt.wantActive {
+				t.Errorf("%q.IsActive() = %v, want %v", tt.stage, got, tt.wantActive)
+			}
+		})
+	}
+}
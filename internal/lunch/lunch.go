@@ -0,0 +1,377 @@
+package lunch
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// linkWindow is how long after it was opened an organiser's order can be linked to a session
+const linkWindow = 6 * time.Hour
+
+// EnsureIndexes creates the indexes used by the lunch sessions collection.
+func EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.Index{
+		{
+			Keys:   []mongo.SortKey{{Key: "session_id", Order: mongo.ASC}},
+			Unique: true,
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "organiser_id", Order: mongo.ASC}, {Key: "created_at", Order: mongo.DSC}},
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "organiser_id", Order: mongo.ASC}, {Key: "provider", Order: mongo.ASC}, {Key: "order_id", Order: mongo.ASC}},
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "status", Order: mongo.ASC}, {Key: "cutoff", Order: mongo.ASC}},
+		},
+	}
+	return env.MongoClient().CreateIndexes(ctx, env.MongoLunchSessionsCollectionName, indexes)
+}
+
+// Open posts a new lunch session to its channel and stores it. The organiser joins it right away.
+func Open(ctx context.Context, api *slack.Client, session *models.LunchSession) error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate session id: %w", err)
+	}
+	now := time.Now()
+	session.SessionId = hex.EncodeToString(id)
+	session.Status = models.LunchStatusOpen
+	session.Participants = []string{session.OrganiserId}
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	blocks, text := card.LunchSession(session)
+	_, ts, err := api.PostMessageContext(ctx, session.ChannelId, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+	if err != nil {
+		return fmt.Errorf("failed to post lunch session: %w", err)
+	}
+	session.MessageTs = ts
+
+	return env.MongoClient().Insert(ctx, env.MongoLunchSessionsCollectionName, session)
+}
+
+// Get returns the lunch session with the given id
+func Get(ctx context.Context, sessionId string) (*models.LunchSession, error) {
+	var session *models.LunchSession
+	filters := mongo.Filters{
+		{
+			Key:      "session_id",
+			Value:    sessionId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoLunchSessionsCollectionName, filters, nil, &session)
+	return session, err
+}
+
+// Join adds userId to the participants of the open session
+func Join(ctx context.Context, api *slack.Client, session *models.LunchSession, userId string) (*models.LunchSession, error) {
+	updates := mongo.Updates{
+		{
+			Key:            "participants",
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.ADD_TO_SET,
+		},
+	}
+	return update(ctx, api, session, openFilter(), updates)
+}
+
+// Leave removes userId from the participants of the open session and their vote
+func Leave(ctx context.Context, api *slack.Client, session *models.LunchSession, userId string) (*models.LunchSession, error) {
+	updates := mongo.Updates{
+		{
+			Key:            "participants",
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.PULL,
+		},
+		{
+			Key:            "options.$[].votes",
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.PULL,
+		},
+	}
+	return update(ctx, api, session, openFilter(), updates)
+}
+
+// Vote moves userId's vote to the option at index, voting also joins the session
+func Vote(ctx context.Context, api *slack.Client, session *models.LunchSession, userId string, index int) (*models.LunchSession, error) {
+	if index < 0 || index >= len(session.Options) {
+		return nil, fmt.Errorf("invalid option %d", index)
+	}
+	// a path can't be pulled from and added to in one update, the old vote goes first
+	updates := mongo.Updates{
+		{
+			Key:            "participants",
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.ADD_TO_SET,
+		},
+		{
+			Key:            "options.$[].votes",
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.PULL,
+		},
+	}
+	if err := env.MongoClient().Update(ctx, env.MongoLunchSessionsCollectionName, append(sessionFilters(session), openFilter()...), updates); err != nil {
+		return nil, fmt.Errorf("failed to update lunch session: %w", err)
+	}
+
+	updates = mongo.Updates{
+		{
+			Key:            fmt.Sprintf("options.%d.votes", index),
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.ADD_TO_SET,
+		},
+	}
+	return update(ctx, api, session, openFilter(), updates)
+}
+
+// Winner returns the option with the most votes, the first suggested one on a tie
+func Winner(session *models.LunchSession) *models.LunchOption {
+	var winner *models.LunchOption
+	for _, option := range session.Options {
+		if winner == nil || len(option.Votes) > len(winner.Votes) {
+			winner = option
+		}
+	}
+	return winner
+}
+
+// update applies updates to the session if it still matches filters and refreshes
+// its message from the stored session, which is returned. Each change only touches
+// its own fields so concurrent clicks don't overwrite each other. mongo.NoItemFound
+// is returned if the session no longer matches filters.
+func update(ctx context.Context, api *slack.Client, session *models.LunchSession, filters mongo.Filters, updates mongo.Updates) (*models.LunchSession, error) {
+	updates = append(updates, mongo.Update{
+		Key:            "updated_at",
+		Value:          time.Now(),
+		Type:           mongo.TIME,
+		UpdateOperator: mongo.SET,
+	})
+	var updated *models.LunchSession
+	err := env.MongoClient().FindOneAndUpdate(ctx, env.MongoLunchSessionsCollectionName, append(sessionFilters(session), filters...), updates, &updated)
+	if err == mongo.NoItemFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update lunch session: %w", err)
+	}
+
+	blocks, text := card.LunchSession(updated)
+	_, _, _, err = api.UpdateMessageContext(ctx, updated.ChannelId, updated.MessageTs, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update lunch session message: %w", err)
+	}
+	return updated, nil
+}
+
+// Close ends voting on an open session and asks the organiser to order from the winning restaurant.
+func Close(ctx context.Context, api *slack.Client, session *models.LunchSession) error {
+	session, err := update(ctx, api, session, openFilter(), statusUpdates(models.LunchStatusClosed))
+	if err == mongo.NoItemFound {
+		// closed already, by the organiser or the cutoff job
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("Lunch is closed with %d joined.", len(session.Participants))
+	if winner := Winner(session); winner != nil && len(winner.Votes) > 0 {
+		text = fmt.Sprintf("Lunch is closed with %d joined, *%s* won the poll.", len(session.Participants), winner.Name)
+	}
+	text += fmt.Sprintf(" <@%s>, your next order will be linked to this lunch.", session.OrganiserId)
+	return reply(ctx, api, session, text, false)
+}
+
+// OnOrderUpdate links a new order of an organiser to their latest lunch session
+// and pings everyone who joined once the linked order is delivered.
+func OnOrderUpdate(ctx context.Context, api *slack.Client, record *models.OrderRecord, stage lifecycle.Stage) error {
+	session, err := linkedSession(ctx, record)
+	if err == mongo.NoItemFound {
+		if !stage.IsActive() {
+			return nil
+		}
+		session, err = link(ctx, api, record)
+	}
+	if err == mongo.NoItemFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if stage != lifecycle.Delivered || session.Status == models.LunchStatusDelivered {
+		return nil
+	}
+	filters := mongo.Filters{
+		{
+			Key:      "status",
+			Value:    models.LunchStatusOrdered,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	session, err = update(ctx, api, session, filters, statusUpdates(models.LunchStatusDelivered))
+	if err == mongo.NoItemFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return reply(ctx, api, session, fmt.Sprintf(":tada: %s lunch is here!", util.Mentions(session.Participants)), true)
+}
+
+// linkedSession returns the session the order is linked to, only the organiser's orders are ever linked
+func linkedSession(ctx context.Context, record *models.OrderRecord) (*models.LunchSession, error) {
+	var session *models.LunchSession
+	filters := mongo.Filters{
+		{
+			Key:      "organiser_id",
+			Value:    record.UserId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "provider",
+			Value:    record.Provider,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "order_id",
+			Value:    record.OrderId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoLunchSessionsCollectionName, filters, nil, &session)
+	return session, err
+}
+
+// link attaches the order to the organiser's most recent session still waiting for
+// one, orders placed before the session was opened are never linked
+func link(ctx context.Context, api *slack.Client, record *models.OrderRecord) (*models.LunchSession, error) {
+	var sessions []*models.LunchSession
+	waiting := mongo.Filter{
+		Key:      "status",
+		Value:    []string{models.LunchStatusOpen, models.LunchStatusClosed},
+		Operator: mongo.IN,
+	}
+	filters := mongo.Filters{
+		{
+			Key:      "organiser_id",
+			Value:    record.UserId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		waiting,
+		{
+			Key:      "created_at",
+			Value:    mongo.Range{Left: record.CreatedAt.Add(-linkWindow), Right: record.CreatedAt},
+			Type:     mongo.TIME,
+			Operator: mongo.BETWEEN,
+		},
+	}
+	sortKeys := []mongo.SortKey{{Key: "created_at", Order: mongo.DSC}}
+	_, err := env.MongoClient().GetSorted(ctx, env.MongoLunchSessionsCollectionName, filters, "", 1, sortKeys, &sessions)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, mongo.NoItemFound
+	}
+
+	updates := append(statusUpdates(models.LunchStatusOrdered),
+		mongo.Update{
+			Key:            "provider",
+			Value:          record.Provider,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		},
+		mongo.Update{
+			Key:            "order_id",
+			Value:          record.OrderId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		},
+	)
+	// another update of the order may have linked it in the meantime
+	session, err := update(ctx, api, sessions[0], mongo.Filters{waiting}, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	restaurant := "the restaurant"
+	if record.Order != nil && record.Order.RestaurantName != "" {
+		restaurant = record.Order.RestaurantName
+	}
+	text := fmt.Sprintf(":white_check_mark: <@%s> ordered from %s (`%s`), you'll be pinged when it arrives.", session.OrganiserId, restaurant, record.OrderId)
+	if err := reply(ctx, api, session, text, false); err != nil {
+		log.Printf("[Lunch] Failed to announce order of session %s: %v\n", session.SessionId, err)
+	}
+	return session, nil
+}
+
+// reply posts text in the session message's thread, broadcast also shows it in the channel
+func reply(ctx context.Context, api *slack.Client, session *models.LunchSession, text string, broadcast bool) error {
+	options := []slack.MsgOption{slack.MsgOptionText(text, false), slack.MsgOptionTS(session.MessageTs)}
+	if broadcast {
+		options = append(options, slack.MsgOptionBroadcast())
+	}
+	_, _, err := api.PostMessageContext(ctx, session.ChannelId, options...)
+	return err
+}
+
+func sessionFilters(session *models.LunchSession) mongo.Filters {
+	return mongo.Filters{
+		{
+			Key:      "session_id",
+			Value:    session.SessionId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+}
+
+// openFilter matches sessions still open for joining and voting
+func openFilter() mongo.Filters {
+	return mongo.Filters{
+		{
+			Key:      "status",
+			Value:    models.LunchStatusOpen,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+}
+
+func statusUpdates(status string) mongo.Updates {
+	return mongo.Updates{
+		{
+			Key:            "status",
+			Value:          status,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// lunch session statuses
+const (
+	LunchStatusOpen      = "open"      // teammates can join and vote until the cutoff
+	LunchStatusClosed    = "closed"    // cutoff passed, waiting for the organiser's order
+	LunchStatusOrdered   = "ordered"   // the organiser's order is linked to the session
+	LunchStatusDelivered = "delivered" // the linked order was delivered and participants pinged
+)
+
+// LunchSession is a group lunch opened in a channel with /st-lunch
+type LunchSession struct {
+	SessionId   string         `bson:"session_id" json:"session_id"`
+	TeamId      string         `bson:"team_id" json:"team_id"`
+	ChannelId   string         `bson:"channel_id" json:"channel_id"`
+	OrganiserId string         `bson:"organiser_id" json:"organiser_id"`
+	Cutoff      time.Time      `bson:"cutoff" json:"cutoff"`
+	Status      string         `bson:"status" json:"status"`
+	Options     []*LunchOption `bson:"options" json:"options"`
+	// Participants are the user ids who joined, the organiser included
+	Participants []string `bson:"participants" json:"participants"`
+	// MessageTs is the session message, updated on every change
+	MessageTs string `bson:"message_ts" json:"message_ts"`
+
+	// Provider and OrderId identify the organiser's order once it is linked
+	Provider string `bson:"provider" json:"provider"`
+	OrderId  string `bson:"order_id" json:"order_id"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// LunchOption is a restaurant suggestion of a lunch session's poll
+type LunchOption struct {
+	Name  string   `bson:"name" json:"name"`
+	Votes []string `bson:"votes" json:"votes"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+)
+
+// flags set on timeline events that were not applied cleanly
+const (
+	OrderFlagUnknownStatus = "unknown_status"
+	OrderFlagRegression    = "regression"
+	OrderFlagAfterTerminal = "after_terminal"
+)
+
+// Order is a delivery order normalized across providers
+type Order struct {
+	Provider             string           `bson:"provider" json:"provider"`
+	OrderId              string           `bson:"order_id" json:"order_id"`
+	Status               *ProviderStatus  `bson:"status" json:"status"`
+	RestaurantName       string           `bson:"restaurant_name" json:"restaurant_name"`
+	DeliveryLabel        string           `bson:"delivery_label" json:"delivery_label"`
+	DeliveryMessage      string           `bson:"delivery_message" json:"delivery_message"`
+	DeliveryAddress      *DeliveryAddress `bson:"delivery_address" json:"delivery_address"`
+	ExpectedDeliveryTime *time.Time       `bson:"expected_delivery_time" json:"expected_delivery_time"`
+	Items                []*OrderItem     `bson:"items" json:"items"`
+	Bill                 *Bill            `bson:"bill" json:"bill"`
+}
+
+// OrderItem is a line item of an order, prices are in minor currency units
+type OrderItem struct {
+	Name       string `bson:"name" json:"name"`
+	Quantity   int    `bson:"quantity" json:"quantity"`
+	UnitPrice  int64  `bson:"unit_price" json:"unit_price"`
+	TotalPrice int64  `bson:"total_price" json:"total_price"`
+}
+
+// Bill holds an order's charges in minor currency units, e.g. paise for INR
+type Bill struct {
+	Currency    string `bson:"currency" json:"currency"`
+	Subtotal    int64  `bson:"subtotal" json:"subtotal"`
+	Taxes       int64  `bson:"taxes" json:"taxes"`
+	DeliveryFee int64  `bson:"delivery_fee" json:"delivery_fee"`
+	Discount    int64  `bson:"discount" json:"discount"`
+	Total       int64  `bson:"total" json:"total"`
+}
+
+// ProviderStatus holds the raw status codes reported by a provider, they are
+// mapped to a lifecycle stage by the provider adapter.
+type ProviderStatus struct {
+	Status         string `bson:"status" json:"status"`
+	PaymentStatus  string `bson:"payment_status" json:"payment_status"`
+	DeliveryStatus string `bson:"delivery_status" json:"delivery_status"`
+}
+
+// DeliveryAddress is the address an order is delivered to. Id matches the
+// address ids saved from the extension popup.
+type DeliveryAddress struct {
+	Id    string `bson:"id" json:"id"`
+	Label string `bson:"label" json:"label"`
+}
+
+// OrderRecord is the stored history of a single order, keyed by provider and order id.
+// Order holds the latest snapshot received, Timeline every status transition.
+type OrderRecord struct {
+	Provider  string          `bson:"provider" json:"provider"`
+	OrderId   string          `bson:"order_id" json:"order_id"`
+	UserId    string          `bson:"user_id" json:"user_id"`
+	Order     *Order          `bson:"order" json:"order"`
+	Stage     lifecycle.Stage `bson:"stage" json:"stage"`
+	Active    bool            `bson:"active" json:"active"`
+	Timeline  []*OrderEvent   `bson:"timeline" json:"timeline"`
+	Messages  []*SlackMessage `bson:"messages" json:"messages"`
+	CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `bson:"updated_at" json:"updated_at"`
+	// DeliveredAt is set when the order first reaches the delivered stage
+	DeliveredAt *time.Time `bson:"delivered_at" json:"delivered_at"`
+
+	// LastSeenAt is the last time the extension reported the order, including duplicates
+	LastSeenAt time.Time `bson:"last_seen_at" json:"last_seen_at"`
+	// TrackingLost is set when the extension stopped reporting the order before it finished
+	TrackingLost bool `bson:"tracking_lost" json:"tracking_lost"`
+
+	// Late is set once an order missed its expected delivery time
+	Late         bool       `bson:"late" json:"late"`
+	EtaAlertedAt *time.Time `bson:"eta_alerted_at" json:"eta_alerted_at"`
+
+	// AckedAt is set once someone confirmed a delivered order was picked up
+	AckedAt *time.Time `bson:"acked_at" json:"acked_at"`
+	AckedBy string     `bson:"acked_by" json:"acked_by"`
+	// Reminders counts the pings sent for a delivered order nobody acknowledged
+	Reminders      int        `bson:"reminders" json:"reminders"`
+	LastRemindedAt *time.Time `bson:"last_reminded_at" json:"last_reminded_at"`
+
+	// Split is set when the order was shared with teammates using /st-split
+	Split *Split `bson:"split" json:"split"`
+}
+
+// OrderEvent is a single entry of an order's timeline
+type OrderEvent struct {
+	Status         string          `bson:"status" json:"status"`
+	Stage          lifecycle.Stage `bson:"stage" json:"stage"`
+	DeliveryStatus string          `bson:"delivery_status" json:"delivery_status"`
+	Label          string          `bson:"label" json:"label"`
+	Message        string          `bson:"message" json:"message"`
+	Flag           string          `bson:"flag,omitempty" json:"flag,omitempty"`
+	At             time.Time       `bson:"at" json:"at"`
+}
+
+// SlackMessage is a message posted for an order, later updates edit it in place.
+// Destination is the channel or user id the message was sent to, ChannelId the
+// conversation Slack actually posted it in (they differ for DMs).
+type SlackMessage struct {
+	Destination string `bson:"destination" json:"destination"`
+	ChannelId   string `bson:"channel_id" json:"channel_id"`
+	Ts          string `bson:"ts" json:"ts"`
+}
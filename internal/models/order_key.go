@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// OrderKey marks an order update as processed until ExpiresAt, used to drop
+// repeated deliveries of the same update.
+type OrderKey struct {
+	Key       string    `bson:"key" json:"key"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
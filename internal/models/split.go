@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Split is how a shared order's bill is divided between the payer and their teammates
+type Split struct {
+	PayerId      string              `bson:"payer_id" json:"payer_id"`
+	Currency     string              `bson:"currency" json:"currency"`
+	Participants []*SplitParticipant `bson:"participants" json:"participants"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// SplitParticipant is a person sharing an order. They pay for the items they
+// are assigned and a share of the unassigned items weighted by Shares. Taxes,
+// fees and discounts are distributed in proportion to that.
+type SplitParticipant struct {
+	UserId string `bson:"user_id" json:"user_id"`
+	// Items are 1-based positions in the order's items, an item assigned to several people is shared equally
+	Items  []int `bson:"items" json:"items"`
+	Shares int   `bson:"shares" json:"shares"`
+	// Amount is what the participant pays in minor currency units
+	Amount int64 `bson:"amount" json:"amount"`
+}
+
+// LedgerEntry is an amount a debtor owes a creditor for a split order, per workspace
+type LedgerEntry struct {
+	TeamId     string     `bson:"team_id" json:"team_id"`
+	DebtorId   string     `bson:"debtor_id" json:"debtor_id"`
+	CreditorId string     `bson:"creditor_id" json:"creditor_id"`
+	Amount     int64      `bson:"amount" json:"amount"`
+	Currency   string     `bson:"currency" json:"currency"`
+	Provider   string     `bson:"provider" json:"provider"`
+	OrderId    string     `bson:"order_id" json:"order_id"`
+	Settled    bool       `bson:"settled" json:"settled"`
+	SettledAt  *time.Time `bson:"settled_at" json:"settled_at"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
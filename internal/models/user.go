@@ -1,14 +1,143 @@
 package models
 
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/shared"
+)
+
 type User struct {
-	UserId     string      `bson:"user_id" json:"user_id"`
-	ChannelId  string      `bson:"channel_id" json:"channel_id"`
-	TeamDomain string      `bson:"team_domain" json:"team_domain"`
-	Schedule   []*Schedule `bson:"schedule" json:"schedule"`
-	AddressIds []string    `bson:"address_ids" json:"address_ids"`
+	UserId    string `bson:"user_id" json:"user_id"`
+	ChannelId string `bson:"channel_id" json:"channel_id"`
+	// Destinations route order updates, the legacy ChannelId is used while there are none
+	Destinations      []*Destination `bson:"destinations" json:"destinations"`
+	TeamDomain        string         `bson:"team_domain" json:"team_domain"`
+	Schedule          []*Schedule    `bson:"schedule" json:"schedule"`
+	AddressIds        []string       `bson:"address_ids" json:"address_ids"`
+	Timezone          string         `bson:"timezone" json:"timezone"`
+	TimezoneCheckedAt time.Time      `bson:"timezone_checked_at" json:"timezone_checked_at"`
+	MessageMode       string         `bson:"message_mode" json:"message_mode"`
+	ShowItems         bool           `bson:"show_items" json:"show_items"`
+	// NotifyStages limits which lifecycle stages are posted, every stage is posted when empty
+	NotifyStages []lifecycle.Stage `bson:"notify_stages" json:"notify_stages"`
+	// MutedRestaurants are restaurant names whose orders are never posted, matched case-insensitively
+	MutedRestaurants []string `bson:"muted_restaurants" json:"muted_restaurants"`
+	// ReminderMinutes and ReminderLimit tune the pings for delivered orders nobody
+	// picked up, defaults are used when they are 0. ReminderBackup is also pinged.
+	ReminderMinutes int    `bson:"reminder_minutes" json:"reminder_minutes"`
+	ReminderLimit   int    `bson:"reminder_limit" json:"reminder_limit"`
+	ReminderBackup  string `bson:"reminder_backup" json:"reminder_backup"`
+	RemindersOff    bool   `bson:"reminders_off" json:"reminders_off"`
+	// Vacation turns notifications off across days, Schedule is kept as is
+	Vacation *Vacation `bson:"vacation" json:"vacation"`
+	// SnoozedUntil holds back updates until then, delivered updates break through if SnoozeBreakthrough is set
+	SnoozedUntil       *time.Time `bson:"snoozed_until" json:"snoozed_until"`
+	SnoozeBreakthrough bool       `bson:"snooze_breakthrough" json:"snooze_breakthrough"`
+	// HeldUpdates are updates held back by a snooze or Slack DND, summarised once it ends
+	HeldUpdates []*HeldUpdate `bson:"held_updates" json:"held_updates"`
+	// Digests are the digest types the user opted in to, see shared.Digest*
+	Digests        []string             `bson:"digests" json:"digests"`
+	DigestDelivery string               `bson:"digest_delivery" json:"digest_delivery"`
+	DigestsSentAt  map[string]time.Time `bson:"digests_sent_at" json:"digests_sent_at"`
+}
+
+// Vacation is an inclusive range of dates, formatted as shared.VacationDateFormat,
+// in the user's timezone
+type Vacation struct {
+	From string `bson:"from" json:"from"`
+	To   string `bson:"to" json:"to"`
+}
+
+// Contains reports whether t falls on a vacation day, t is expected to be in the user's location
+func (v *Vacation) Contains(t time.Time) bool {
+	if v == nil {
+		return false
+	}
+	date := t.Format(shared.VacationDateFormat)
+	return date >= v.From && date <= v.To
+}
+
+// HeldUpdate is an order update that was not posted while the user was snoozed
+type HeldUpdate struct {
+	Provider       string          `bson:"provider" json:"provider"`
+	OrderId        string          `bson:"order_id" json:"order_id"`
+	RestaurantName string          `bson:"restaurant_name" json:"restaurant_name"`
+	Stage          lifecycle.Stage `bson:"stage" json:"stage"`
+	Label          string          `bson:"label" json:"label"`
+	At             time.Time       `bson:"at" json:"at"`
 }
 
+// Schedule is a daily window, formatted as shared.ScheduleTimeFormat, in which
+// order updates are posted. Windows ending before they start run past midnight.
 type Schedule struct {
 	From string `bson:"from" json:"from"`
 	To   string `bson:"to" json:"to"`
+	// Weekdays limits the window to some days of the week, it applies every day when empty
+	Weekdays []time.Weekday `bson:"weekdays" json:"weekdays"`
+	// Timezone the window is in, the user's Slack timezone is used when empty
+	Timezone string `bson:"timezone" json:"timezone"`
+}
+
+// OnDay reports whether the window applies on day
+func (s *Schedule) OnDay(day time.Weekday) bool {
+	return len(s.Weekdays) == 0 || slices.Contains(s.Weekdays, day)
+}
+
+// Equal reports whether both windows cover the same times and days
+func (s *Schedule) Equal(other *Schedule) bool {
+	return s.From == other.From && s.To == other.To && s.Timezone == other.Timezone && slices.Equal(s.Weekdays, other.Weekdays)
+}
+
+// defaults for delivered order reminders
+const (
+	DefaultReminderMinutes = 10
+	DefaultReminderLimit   = 3
+)
+
+// ReminderSettings returns how often and how many times the user is reminded of a delivered order
+func (u *User) ReminderSettings() (time.Duration, int) {
+	minutes, limit := u.ReminderMinutes, u.ReminderLimit
+	if minutes <= 0 {
+		minutes = DefaultReminderMinutes
+	}
+	if limit <= 0 {
+		limit = DefaultReminderLimit
+	}
+	return time.Duration(minutes) * time.Minute, limit
+}
+
+// WantsStage reports whether the user wants updates in stage posted
+func (u *User) WantsStage(stage lifecycle.Stage) bool {
+	return len(u.NotifyStages) == 0 || slices.Contains(u.NotifyStages, stage)
+}
+
+// IsMuted reports whether the user muted orders from restaurant
+func (u *User) IsMuted(restaurant string) bool {
+	return slices.ContainsFunc(u.MutedRestaurants, func(muted string) bool {
+		return strings.EqualFold(strings.TrimSpace(muted), strings.TrimSpace(restaurant))
+	})
+}
+
+// Destination is a channel or the user's DM that order updates are posted to
+type Destination struct {
+	Type      string `bson:"type" json:"type"`
+	ChannelId string `bson:"channel_id" json:"channel_id"`
+	// Stages limits which lifecycle stages are posted, every stage is posted when empty
+	Stages []lifecycle.Stage `bson:"stages" json:"stages"`
+}
+
+// Target returns the conversation to post to, the user's id for DMs
+func (d *Destination) Target(userId string) string {
+	if d.Type == shared.DestinationDM {
+		return userId
+	}
+	return d.ChannelId
+}
+
+// Wants reports whether updates in stage are posted to the destination
+func (d *Destination) Wants(stage lifecycle.Stage) bool {
+	return len(d.Stages) == 0 || slices.Contains(d.Stages, stage)
 }
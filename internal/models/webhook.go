@@ -5,27 +5,12 @@ type UpdateUserSettings struct {
 	StartTime  []string `json:"startTime"`
 	EndTime    []string `json:"endTime"`
 	AddressIds []string `json:"addressIds"`
-}
-
-type OrderUpdate struct {
-	Order   *ZomatoOrder `json:"order"`
-	SlackId string       `json:"slackId"`
-}
-
-type ZomatoOrder struct {
-	OrderId         uint64           `json:"orderId"`
-	Status          int              `json:"status"`
-	PaymentStatus   int              `json:"paymentStatus"`
-	DeliveryDetails *DeliveryDetails `json:"deliveryDetails"`
-	ResInfo         *ResInfo         `json:"resInfo"`
-}
-
-type DeliveryDetails struct {
-	DeliveryStatus  int    `json:"deliveryStatus"`
-	DeliveryLabel   string `json:"deliveryLabel"`
-	DeliveryMessage string `json:"deliveryMessage"`
-}
-
-type ResInfo struct {
-	Name string `json:"name"`
+	// NotifyStages, MutedRestaurants, Channels and DirectMessage are left unchanged when not sent
+	NotifyStages     *[]string `json:"notifyStages"`
+	MutedRestaurants *[]string `json:"mutedRestaurants"`
+	Channels         *[]string `json:"channels"`
+	DirectMessage    *bool     `json:"directMessage"`
+	// Weekdays holds the days, like `mon`, of each schedule window. Windows sent
+	// without it keep the days they were saved with.
+	Weekdays *[][]string `json:"weekdays"`
 }
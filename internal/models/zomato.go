@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ZomatoOrderUpdate is the payload posted by the browser extension for a Zomato order
+type ZomatoOrderUpdate struct {
+	Order   *ZomatoOrder `json:"order"`
+	SlackId string       `json:"slackId"`
+}
+
+type ZomatoOrder struct {
+	OrderId         uint64           `json:"orderId"`
+	Status          int              `json:"status"`
+	PaymentStatus   int              `json:"paymentStatus"`
+	DeliveryDetails *DeliveryDetails `json:"deliveryDetails"`
+	DeliveryAddress *ZomatoAddress   `json:"deliveryAddress"`
+	ResInfo         *ResInfo         `json:"resInfo"`
+	// ExpectedDeliveryTime is the delivery time promised by Zomato, see ZomatoTime
+	ExpectedDeliveryTime ZomatoTime    `json:"expectedDeliveryTime"`
+	Items                []*ZomatoItem `json:"items"`
+	Bill                 *ZomatoBill   `json:"bill"`
+}
+
+// ZomatoItem is an ordered dish, prices are in minor currency units
+type ZomatoItem struct {
+	Name       string `json:"name"`
+	Quantity   int    `json:"quantity"`
+	UnitPrice  int64  `json:"unitPrice"`
+	TotalPrice int64  `json:"totalPrice"`
+}
+
+// ZomatoBill holds the order's charges in minor currency units
+type ZomatoBill struct {
+	Currency    string `json:"currency"`
+	Taxes       int64  `json:"taxes"`
+	DeliveryFee int64  `json:"deliveryFee"`
+	Discount    int64  `json:"discount"`
+	Total       int64  `json:"total"`
+}
+
+// ZomatoTime decodes an RFC 3339 string or a unix timestamp in seconds or
+// milliseconds. Values in any other format are ignored instead of failing the
+// whole update, the time is then zero.
+type ZomatoTime struct {
+	time.Time
+}
+
+func (t *ZomatoTime) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			log.Printf("[Zomato] Ignoring time %q: %v\n", v, err)
+			return nil
+		}
+		t.Time = parsed
+	case float64:
+		// seconds only get this large in a few thousand years, these are milliseconds
+		if v > 1e12 {
+			t.Time = time.UnixMilli(int64(v))
+		} else if v > 0 {
+			t.Time = time.Unix(int64(v), 0)
+		}
+	}
+	return nil
+}
+
+// ZomatoAddress is the address an order is delivered to, Zomato address ids
+// are numbers but older extension builds send them as strings
+type ZomatoAddress struct {
+	Id    ZomatoId `json:"id"`
+	Label string   `json:"label"`
+}
+
+// ZomatoId decodes an id sent either as a JSON number or a string
+type ZomatoId string
+
+func (id *ZomatoId) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*id = ZomatoId(number.String())
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("id must be a number or a string: %w", err)
+	}
+	*id = ZomatoId(s)
+	return nil
+}
+
+type DeliveryDetails struct {
+	DeliveryStatus  int    `json:"deliveryStatus"`
+	DeliveryLabel   string `json:"deliveryLabel"`
+	DeliveryMessage string `json:"deliveryMessage"`
+}
+
+type ResInfo struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,87 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// Get returns the user's stored order with the given provider and id
+func Get(ctx context.Context, provider, userId, orderId string) (*models.OrderRecord, error) {
+	var record *models.OrderRecord
+	err := env.MongoClient().GetOne(ctx, env.MongoOrdersCollectionName, keyFilters(provider, userId, orderId), nil, &record)
+	return record, err
+}
+
+// Acknowledge records that userId picked up a delivered order and replaces the
+// "I've got it" button on its messages. Only the first acknowledgement counts,
+// ok is false if the order was already acknowledged.
+func Acknowledge(ctx context.Context, api *slack.Client, record *models.OrderRecord, userId string) (bool, error) {
+	filters := keyFilters(record.Provider, record.UserId, record.OrderId)
+	filters.Append(mongo.Filter{
+		Key:      "acked_at",
+		Value:    nil,
+		Operator: mongo.EQUAL,
+	})
+	updates := mongo.Updates{
+		{
+			Key:            "acked_at",
+			Value:          time.Now(),
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+		{
+			Key:            "acked_by",
+			Value:          userId,
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	err := env.MongoClient().FindOneAndUpdate(ctx, env.MongoOrdersCollectionName, filters, updates, &record)
+	if err == mongo.NoItemFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acknowledge order: %w", err)
+	}
+
+	var user *models.User
+	if err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, userFilters(record.UserId), nil, &user); err != nil {
+		return true, fmt.Errorf("failed to get owner of order: %w", err)
+	}
+
+	blocks, text := card.Order(record.UserId, record.Order, record.Stage, record.UpdatedAt, user.ShowItems)
+	blocks = append(blocks, card.Acknowledgement(record))
+	for _, msg := range record.Messages {
+		_, _, _, err := api.UpdateMessageContext(ctx, msg.ChannelId, msg.Ts, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+		if err != nil {
+			log.Printf("[OrderAck] Failed to update message of order %s in %s: %v\n", record.OrderId, msg.ChannelId, err)
+		}
+	}
+	return true, nil
+}
+
+// MarkReminded counts a reminder sent for a delivered order nobody acknowledged.
+func MarkReminded(ctx context.Context, record *models.OrderRecord, at time.Time) error {
+	updates := mongo.Updates{
+		{
+			Key:            "reminders",
+			Value:          1,
+			UpdateOperator: mongo.INC,
+		},
+		{
+			Key:            "last_reminded_at",
+			Value:          at,
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoOrdersCollectionName, keyFilters(record.Provider, record.UserId, record.OrderId), updates)
+}
@@ -0,0 +1,109 @@
+package order
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+)
+
+// defaultDedupWindow is used when ORDER_DEDUP_WINDOW is not set
+const defaultDedupWindow = 10 * time.Minute
+
+// Fingerprint identifies the state of a user's order, two updates with the same fingerprint carry the same information.
+func Fingerprint(userId string, o *models.Order) string {
+	status := o.Status
+	if status == nil {
+		status = &models.ProviderStatus{}
+	}
+	raw := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s", o.Provider, userId, o.OrderId, status.Status, status.DeliveryStatus, o.DeliveryLabel, status.PaymentStatus)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// Claim marks key as processed for the dedup window. It returns false if the key
+// was already claimed within the window, i.e. the update is a duplicate.
+func Claim(ctx context.Context, key string) (bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(env.GetDurationParam(env.OrderDedupWindow, defaultDedupWindow))
+
+	updates := mongo.Updates{
+		{
+			Key:            "expires_at",
+			Value:          expiresAt,
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	filters := append(orderKeyFilters(key), mongo.Filter{
+		Key:      "expires_at",
+		Value:    now,
+		Type:     mongo.TIME,
+		Operator: mongo.LESS_THAN,
+	})
+	for attempt := 0; attempt < 2; attempt++ {
+		err := env.MongoClient().Insert(ctx, env.MongoOrderKeysCollectionName, &models.OrderKey{Key: key, ExpiresAt: expiresAt})
+		if err == nil {
+			return true, nil
+		}
+		if !mongo.IsDuplicateKey(err) {
+			return false, fmt.Errorf("failed to insert order key: %w", err)
+		}
+
+		// expired keys are only removed by mongo every minute, they can still be
+		// around. Taking one over only succeeds for one of concurrent retries.
+		var existing *models.OrderKey
+		err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoOrderKeysCollectionName, filters, updates, &existing)
+		if err == nil {
+			return true, nil
+		}
+		if err != mongo.NoItemFound {
+			return false, fmt.Errorf("failed to refresh order key: %w", err)
+		}
+		// the key is still valid, or it was removed in between and inserting it once more decides
+	}
+	return false, nil
+}
+
+// ClaimAll claims every key, an update is a duplicate if any of them was
+// already claimed. Keys claimed before a duplicate was found are released again.
+func ClaimAll(ctx context.Context, keys ...string) (bool, error) {
+	for i, key := range keys {
+		claimed, err := Claim(ctx, key)
+		if err == nil && claimed {
+			continue
+		}
+		if releaseErr := Release(ctx, keys[:i]...); releaseErr != nil {
+			log.Printf("[OrderUpdate] Failed to release dedup keys: %v\n", releaseErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Release removes claimed keys so a retry of a failed update is not treated as a duplicate.
+func Release(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if _, err := env.MongoClient().Delete(ctx, env.MongoOrderKeysCollectionName, orderKeyFilters(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func orderKeyFilters(key string) mongo.Filters {
+	return mongo.Filters{
+		{
+			Key:      "key",
+			Value:    key,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+}
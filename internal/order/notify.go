@@ -0,0 +1,103 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// Notify posts an order update to channelId following the user's message mode.
+// blocks and text are the order card and its fallback, reply the short line used for thread replies.
+// record may be nil if the order could not be stored, in which case a new message is posted.
+func Notify(ctx context.Context, api *slack.Client, user *models.User, record *models.OrderRecord, transitioned bool, channelId string, blocks []slack.Block, text, reply string) error {
+	mode := user.MessageMode
+	if mode == "" {
+		mode = shared.MessageModeThread
+	}
+
+	msg := findMessage(record, channelId)
+	if mode == shared.MessageModeLegacy || record == nil || msg == nil {
+		channel, ts, err := api.PostMessageContext(ctx, channelId, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+		if err != nil {
+			return fmt.Errorf("failed to post message: %w", err)
+		}
+		if mode == shared.MessageModeLegacy || record == nil {
+			return nil
+		}
+		if err := SaveMessage(ctx, record, &models.SlackMessage{Destination: channelId, ChannelId: channel, Ts: ts}); err != nil {
+			log.Printf("[OrderNotify] Failed to save message for order %s: %v\n", record.OrderId, err)
+		}
+		return nil
+	}
+
+	_, _, _, err := api.UpdateMessageContext(ctx, msg.ChannelId, msg.Ts, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+	if err != nil {
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	if mode == shared.MessageModeThread && transitioned {
+		_, _, err = api.PostMessageContext(ctx, msg.ChannelId, slack.MsgOptionText(reply, false), slack.MsgOptionTS(msg.Ts))
+		if err != nil {
+			return fmt.Errorf("failed to post thread reply: %w", err)
+		}
+	}
+	return nil
+}
+
+// PostFollowUp posts text about an existing order as a thread reply on its
+// message in each of the user's destinations that wants the order's stage.
+// Destinations the order was never posted to are skipped, except in legacy mode
+// where messages are not stored and text is posted as a new message. posted is
+// false if nothing was sent.
+func PostFollowUp(ctx context.Context, api *slack.Client, user *models.User, record *models.OrderRecord, text string) (bool, error) {
+	posted := false
+	for _, d := range util.GetDestinations(user) {
+		if !d.Wants(record.Stage) {
+			continue
+		}
+		target := d.Target(user.UserId)
+		if msg := findMessage(record, target); msg != nil {
+			_, _, err := api.PostMessageContext(ctx, msg.ChannelId, slack.MsgOptionText(text, false), slack.MsgOptionTS(msg.Ts))
+			if err != nil {
+				return posted, fmt.Errorf("failed to post thread reply in %s: %w", msg.ChannelId, err)
+			}
+			posted = true
+			continue
+		}
+		if user.MessageMode != shared.MessageModeLegacy {
+			continue
+		}
+		if _, _, err := api.PostMessageContext(ctx, target, slack.MsgOptionText(text, false)); err != nil {
+			return posted, fmt.Errorf("failed to post to %s: %w", target, err)
+		}
+		posted = true
+	}
+	return posted, nil
+}
+
+// IsValidMessageMode reports whether mode is one of the supported message modes
+func IsValidMessageMode(mode string) bool {
+	switch mode {
+	case shared.MessageModeEdit, shared.MessageModeThread, shared.MessageModeLegacy:
+		return true
+	default:
+		return false
+	}
+}
+
+func findMessage(record *models.OrderRecord, channelId string) *models.SlackMessage {
+	if record == nil {
+		return nil
+	}
+	for _, msg := range record.Messages {
+		if msg.Destination == channelId {
+			return msg
+		}
+	}
+	return nil
+}
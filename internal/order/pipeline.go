@@ -0,0 +1,203 @@
+package order
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/home"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/lunch"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/provider"
+	"github.com/diabolusgx/snack-track/internal/schedule"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/slack-go/slack"
+)
+
+// Outcome is what the pipeline did with an order update
+type Outcome string
+
+const (
+	OutcomePosted    Outcome = "posted"
+	OutcomeDuplicate Outcome = "duplicate"
+	OutcomeSkipped   Outcome = "skipped"
+	OutcomeHeld      Outcome = "held"
+)
+
+// Process runs an order update reported for user through duplicate suppression,
+// order history and the user's filters, and posts it to Slack.
+// idempotencyKey is optional, it is checked on top of the order's fingerprint.
+func Process(ctx context.Context, api *slack.Client, p provider.Provider, user *models.User, o *models.Order, idempotencyKey string) (Outcome, error) {
+	// drop updates already processed within the dedup window, the extension
+	// can report the same state from several places and browsers, each with
+	// its own idempotency keys
+	dedupKeys := []string{Fingerprint(user.UserId, o)}
+	if idempotencyKey != "" {
+		dedupKeys = append(dedupKeys, "idempotency:"+user.UserId+":"+idempotencyKey)
+	}
+	claimed, err := ClaimAll(ctx, dedupKeys...)
+	if err != nil {
+		// fail open, a duplicate post is better than a missed update
+		log.Printf("[OrderUpdate] Failed to check duplicate for order %s: %v\n", o.OrderId, err)
+	} else if !claimed {
+		log.Printf("[OrderUpdate] Dropping duplicate update for order %s of %s\n", o.OrderId, user.UserId)
+		if err := Touch(ctx, user.UserId, o); err != nil {
+			log.Printf("[OrderUpdate] Failed to refresh last seen of order %s: %v\n", o.OrderId, err)
+		}
+		return OutcomeDuplicate, nil
+	}
+
+	change, err := Record(ctx, p, user.UserId, o)
+	if err != nil {
+		log.Printf("[OrderUpdate] Failed to record order %s: %v\n", o.OrderId, err)
+		stage, stageErr := p.MapStatus(o.Status)
+		change = &Change{Stage: stage, Transitioned: true, Anomaly: stageErr}
+	}
+
+	// stale browsers can report an older state of the order, don't post it
+	if change.Rejected() {
+		log.Printf("[OrderUpdate] Dropping update for order %s of %s: %v\n", o.OrderId, user.UserId, change.Anomaly)
+		return OutcomeSkipped, nil
+	}
+
+	// nothing users can see changed once the dedup window is over, e.g. an older
+	// extension build still reporting unchanged orders, there's nothing to post
+	if !change.Transitioned {
+		return OutcomeDuplicate, nil
+	}
+
+	// keep the user's Home tab in sync, whatever happens to the update. It is
+	// refreshed in the background so the extension's request doesn't wait for it.
+	defer home.PublishAsync(api, user.UserId)
+
+	// lunch sessions follow the organiser's order regardless of their own filters
+	if change.Record != nil {
+		if err := lunch.OnOrderUpdate(ctx, api, change.Record, change.Stage); err != nil {
+			log.Printf("[OrderUpdate] Failed to update lunch session for order %s: %v\n", o.OrderId, err)
+		}
+	}
+
+	// only post orders placed inside the user's schedule windows, later updates
+	// of such an order are posted even once the window is over
+	now := time.Now().In(util.GetUserLocation(ctx, api, user))
+	placedAt := now
+	if change.Record != nil {
+		placedAt = change.Record.CreatedAt.In(now.Location())
+	}
+	if !schedule.IsWithin(user.Schedule, placedAt, now.Location()) {
+		log.Printf("[OrderUpdate] Dropping update for order %s of %s: placed at %s (%s), outside schedule windows\n", o.OrderId, user.UserId, placedAt.Format(shared.ScheduleTimeFormat), now.Location())
+		return OutcomeSkipped, nil
+	}
+
+	// users on vacation keep their schedule but get no updates
+	if user.Vacation.Contains(now) {
+		log.Printf("[OrderUpdate] Dropping update for order %s of %s: on vacation until %s\n", o.OrderId, user.UserId, user.Vacation.To)
+		return OutcomeSkipped, nil
+	}
+
+	// only post orders delivered to one of the user's addresses. Orders whose
+	// address is unknown, e.g. from older extension builds, are posted rather than lost.
+	if o.DeliveryAddress == nil {
+		if len(user.AddressIds) > 0 {
+			log.Printf("[OrderUpdate] Order %s of %s has no delivery address, skipping address filter\n", o.OrderId, user.UserId)
+		}
+	} else if !util.IsAllowedAddress(user.AddressIds, o.DeliveryAddress.Id) {
+		log.Printf("[OrderUpdate] Dropping update for order %s of %s: address %s is not in address filter\n", o.OrderId, user.UserId, o.DeliveryAddress.Id)
+		return OutcomeSkipped, nil
+	}
+
+	// notification preferences, muted restaurants and stages the user doesn't care about
+	if user.IsMuted(o.RestaurantName) {
+		log.Printf("[OrderUpdate] Dropping update for order %s of %s: %s is muted\n", o.OrderId, user.UserId, o.RestaurantName)
+		return OutcomeSkipped, nil
+	}
+	if !user.WantsStage(change.Stage) {
+		log.Printf("[OrderUpdate] Dropping update for order %s of %s: stage %s is not wanted\n", o.OrderId, user.UserId, change.Stage)
+		return OutcomeSkipped, nil
+	}
+
+	// hold updates back while the user is snoozed or in Do Not Disturb
+	if !BreaksThrough(user, change.Stage) && IsQuiet(ctx, api, user, time.Now()) {
+		log.Printf("[OrderUpdate] Holding update for order %s of %s, user is snoozed\n", o.OrderId, user.UserId)
+		if err := Hold(ctx, user, o, change.Stage, time.Now()); err != nil {
+			log.Printf("[OrderUpdate] Failed to hold update for order %s: %v\n", o.OrderId, err)
+		}
+		return OutcomeHeld, nil
+	}
+
+	updatedAt := time.Now()
+	if change.Record != nil {
+		updatedAt = change.Record.UpdatedAt
+	}
+	blocks, text := card.Order(user.UserId, o, change.Stage, updatedAt, user.ShowItems)
+	if change.Stage == lifecycle.Delivered && change.Record != nil {
+		blocks = append(blocks, card.Acknowledgement(change.Record))
+	}
+	reply := card.Reply(o, change.Stage)
+
+	// fan out to every destination that wants the stage, a destination that
+	// fails doesn't hold back the others
+	posted, failed := 0, 0
+	var lastErr error
+	for _, d := range util.GetDestinations(user) {
+		if !d.Wants(change.Stage) {
+			continue
+		}
+		err := Notify(ctx, api, user, change.Record, change.Transitioned, d.Target(user.UserId), blocks, text, reply)
+		if err != nil {
+			log.Printf("[OrderUpdate] Failed to post order %s to %s: %v\n", o.OrderId, d.Target(user.UserId), err)
+			failed, lastErr = failed+1, err
+			continue
+		}
+		posted++
+	}
+
+	if failed > 0 && posted == 0 {
+		if err := Release(ctx, dedupKeys...); err != nil {
+			log.Printf("[OrderUpdate] Failed to release dedup key for order %s: %v\n", o.OrderId, err)
+		}
+		return "", lastErr
+	}
+	if posted == 0 {
+		log.Printf("[OrderUpdate] No destination of %s wants stage %s of order %s\n", user.UserId, change.Stage, o.OrderId)
+		return OutcomeSkipped, nil
+	}
+	return OutcomePosted, nil
+}
+
+// Suppressed returns why notifications about a stored order should not reach the
+// user now, empty if they should. It applies the same schedule, vacation, address,
+// mute and snooze filters as order updates, for notifications sent outside Process.
+func Suppressed(ctx context.Context, api *slack.Client, user *models.User, record *models.OrderRecord, now time.Time) string {
+	if reason := Filtered(ctx, api, user, record, now); reason != "" {
+		return reason
+	}
+	if IsQuiet(ctx, api, user, now) {
+		return "snoozed"
+	}
+	return ""
+}
+
+// Filtered is Suppressed without the snooze and Do Not Disturb check, for
+// notifications that can break through a snooze.
+func Filtered(ctx context.Context, api *slack.Client, user *models.User, record *models.OrderRecord, now time.Time) string {
+	local := now.In(util.GetUserLocation(ctx, api, user))
+	if !schedule.IsWithin(user.Schedule, record.CreatedAt, local.Location()) {
+		return "placed outside schedule windows"
+	}
+	if user.Vacation.Contains(local) {
+		return "on vacation"
+	}
+	if record.Order != nil {
+		if address := record.Order.DeliveryAddress; address != nil && !util.IsAllowedAddress(user.AddressIds, address.Id) {
+			return "address " + address.Id + " is not in address filter"
+		}
+		if user.IsMuted(record.Order.RestaurantName) {
+			return record.Order.RestaurantName + " is muted"
+		}
+	}
+	return ""
+}
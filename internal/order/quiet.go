@@ -0,0 +1,100 @@
+package order
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// IsQuiet reports whether updates for the user are held back at now, because
+// they snoozed Snack Track or Slack's Do Not Disturb is on.
+func IsQuiet(ctx context.Context, api *slack.Client, user *models.User, now time.Time) bool {
+	if user.SnoozedUntil != nil && now.Before(*user.SnoozedUntil) {
+		return true
+	}
+
+	dnd, err := api.GetDNDInfoContext(ctx, &user.UserId)
+	if err != nil {
+		// fail open, a missed DND is better than a missed update
+		log.Printf("[OrderQuiet] Failed to get DND info of %s: %v\n", user.UserId, err)
+		return false
+	}
+	if dnd.SnoozeEnabled && now.Unix() < int64(dnd.SnoozeEndTime) {
+		return true
+	}
+	return dnd.Enabled && now.Unix() >= int64(dnd.NextStartTimestamp) && now.Unix() < int64(dnd.NextEndTimestamp)
+}
+
+// BreaksThrough reports whether an update in stage is posted even while the user is quiet
+func BreaksThrough(user *models.User, stage lifecycle.Stage) bool {
+	return user.SnoozeBreakthrough && stage == lifecycle.Delivered
+}
+
+// Hold stores an update that was held back, to be summarised once the user is no longer quiet.
+func Hold(ctx context.Context, user *models.User, o *models.Order, stage lifecycle.Stage, at time.Time) error {
+	held := &models.HeldUpdate{
+		Provider:       o.Provider,
+		OrderId:        o.OrderId,
+		RestaurantName: o.RestaurantName,
+		Stage:          stage,
+		Label:          o.DeliveryLabel,
+		At:             at,
+	}
+	updates := mongo.Updates{
+		{
+			Key:            "held_updates",
+			Value:          []*models.HeldUpdate{held},
+			UpdateOperator: mongo.PUSH,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoUsersCollectionName, userFilters(user.UserId), updates)
+}
+
+// RefreshCards updates the order's posted cards to its stored state, for cards
+// that missed updates held back while the user was quiet. Orders first seen
+// while quiet have no cards yet, their next update posts one.
+func RefreshCards(ctx context.Context, api *slack.Client, user *models.User, record *models.OrderRecord) {
+	if record.Order == nil || len(record.Messages) == 0 {
+		return
+	}
+	blocks, text := card.Order(user.UserId, record.Order, record.Stage, record.UpdatedAt, user.ShowItems)
+	if record.Stage == lifecycle.Delivered {
+		blocks = append(blocks, card.Acknowledgement(record))
+	}
+	for _, msg := range record.Messages {
+		_, _, _, err := api.UpdateMessageContext(ctx, msg.ChannelId, msg.Ts, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false))
+		if err != nil {
+			log.Printf("[OrderQuiet] Failed to refresh message of order %s in %s: %v\n", record.OrderId, msg.ChannelId, err)
+		}
+	}
+}
+
+// ClearHeld removes the user's held updates up to and including until, updates held later are kept.
+func ClearHeld(ctx context.Context, userId string, until time.Time) error {
+	updates := mongo.Updates{
+		{
+			Key:            "held_updates",
+			Value:          map[string]interface{}{"at": map[string]interface{}{"$lte": until}},
+			UpdateOperator: mongo.PULL,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoUsersCollectionName, userFilters(userId), updates)
+}
+
+func userFilters(userId string) mongo.Filters {
+	return mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+}
@@ -0,0 +1,418 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/provider"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+)
+
+// EnsureIndexes creates the indexes used by the orders and order keys collections.
+func EnsureIndexes(ctx context.Context) error {
+	expireAt := int32(0)
+	keyIndexes := []mongo.Index{
+		{
+			Keys:   []mongo.SortKey{{Key: "key", Order: mongo.ASC}},
+			Unique: true,
+		},
+		{
+			Keys:               []mongo.SortKey{{Key: "expires_at", Order: mongo.ASC}},
+			ExpireAfterSeconds: &expireAt,
+		},
+	}
+	if err := env.MongoClient().CreateIndexes(ctx, env.MongoOrderKeysCollectionName, keyIndexes); err != nil {
+		return err
+	}
+
+	// orders used to be unique per provider and order id alone, which let any
+	// user's payload overwrite another user's order with the same id
+	legacyKey := []mongo.SortKey{{Key: "provider", Order: mongo.ASC}, {Key: "order_id", Order: mongo.ASC}}
+	if err := env.MongoClient().DropIndex(ctx, env.MongoOrdersCollectionName, legacyKey); err != nil {
+		return err
+	}
+
+	indexes := []mongo.Index{
+		{
+			Keys:   []mongo.SortKey{{Key: "provider", Order: mongo.ASC}, {Key: "user_id", Order: mongo.ASC}, {Key: "order_id", Order: mongo.ASC}},
+			Unique: true,
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "user_id", Order: mongo.ASC}, {Key: "created_at", Order: mongo.DSC}},
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "active", Order: mongo.ASC}, {Key: "updated_at", Order: mongo.ASC}},
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "active", Order: mongo.ASC}, {Key: "last_seen_at", Order: mongo.ASC}},
+		},
+	}
+	return env.MongoClient().CreateIndexes(ctx, env.MongoOrdersCollectionName, indexes)
+}
+
+// Change describes the outcome of recording an order update
+type Change struct {
+	Record *models.OrderRecord
+	// Stage is the stage reported by the update, which differs from Record.Stage if it was rejected
+	Stage lifecycle.Stage
+	// Transitioned is set when the update changed the order's status, label or message
+	Transitioned bool
+	// Anomaly is set for unknown status codes and illegal transitions, see lifecycle.Transition
+	Anomaly error
+}
+
+// Rejected reports whether the update was not applied to the order because
+// it would move it backwards or out of a terminal stage.
+func (c *Change) Rejected() bool {
+	return errors.Is(c.Anomaly, lifecycle.ErrRegression) || errors.Is(c.Anomaly, lifecycle.ErrTerminal)
+}
+
+// Record saves the latest snapshot of an order for the user and appends a
+// timeline event if its status, label or message changed. The order's status
+// is mapped to a stage by its provider. Updates that regress the order's stage
+// are kept in the timeline, flagged, but do not replace the snapshot.
+func Record(ctx context.Context, p provider.Provider, userId string, o *models.Order) (*Change, error) {
+	now := time.Now()
+	stage, stageErr := p.MapStatus(o.Status)
+	event := newEvent(o, stage, now)
+	filters := keyFilters(o.Provider, userId, o.OrderId)
+
+	var record *models.OrderRecord
+	err := env.MongoClient().GetOne(ctx, env.MongoOrdersCollectionName, filters, nil, &record)
+	if err == mongo.NoItemFound {
+		if stageErr != nil {
+			event.Flag = flagFor(stageErr)
+		}
+		record = &models.OrderRecord{
+			Provider:   o.Provider,
+			OrderId:    o.OrderId,
+			UserId:     userId,
+			Order:      o,
+			Stage:      stage,
+			Active:     stage.IsActive(),
+			Late:       isLate(o, stage, now),
+			Timeline:   []*models.OrderEvent{event},
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			LastSeenAt: now,
+		}
+		if stage == lifecycle.Delivered {
+			record.DeliveredAt = &now
+		}
+		err = env.MongoClient().Insert(ctx, env.MongoOrdersCollectionName, record)
+		if err == nil {
+			return &Change{Record: record, Stage: stage, Transitioned: true, Anomaly: stageErr}, nil
+		}
+		if !mongo.IsDuplicateKey(err) {
+			return nil, fmt.Errorf("failed to insert order: %w", err)
+		}
+
+		// another update for the same order won the insert, append to it instead
+		record = nil
+		err = env.MongoClient().GetOne(ctx, env.MongoOrdersCollectionName, filters, nil, &record)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	change := &Change{Stage: stage, Anomaly: stageErr}
+	if change.Anomaly == nil {
+		change.Anomaly = lifecycle.Transition(record.Stage, stage)
+	}
+	if change.Anomaly != nil {
+		event.Flag = flagFor(change.Anomaly)
+		log.Printf("[OrderStore] Order %s: %v\n", record.OrderId, change.Anomaly)
+	}
+
+	updates := mongo.Updates{
+		{
+			Key:            "updated_at",
+			Value:          now,
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+		{
+			Key:            "last_seen_at",
+			Value:          now,
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	if !change.Rejected() {
+		updates.Append(mongo.Update{
+			Key:            "tracking_lost",
+			Value:          false,
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		})
+		updates.Append(mongo.Update{
+			Key:            "order",
+			Value:          o,
+			UpdateOperator: mongo.SET,
+		})
+		if stage != lifecycle.Unknown {
+			updates.Append(mongo.Update{
+				Key:            "stage",
+				Value:          stage,
+				Type:           mongo.STRING,
+				UpdateOperator: mongo.SET,
+			}, mongo.Update{
+				Key:            "active",
+				Value:          stage.IsActive(),
+				Type:           mongo.BOOL,
+				UpdateOperator: mongo.SET,
+			})
+		}
+		if stage == lifecycle.Delivered && record.DeliveredAt == nil {
+			updates.Append(mongo.Update{
+				Key:            "delivered_at",
+				Value:          now,
+				Type:           mongo.TIME,
+				UpdateOperator: mongo.SET,
+			})
+		}
+		if isLate(o, stage, now) {
+			updates.Append(mongo.Update{
+				Key:            "late",
+				Value:          true,
+				Type:           mongo.BOOL,
+				UpdateOperator: mongo.SET,
+			})
+		}
+	}
+
+	if isTransition(lastEvent(record), event) {
+		change.Transitioned = !change.Rejected()
+		updates.Append(mongo.Update{
+			Key:            "timeline",
+			Value:          []*models.OrderEvent{event},
+			UpdateOperator: mongo.PUSH,
+		})
+	} else {
+		log.Printf("[OrderStore] Order %s has no status change, timeline not updated\n", record.OrderId)
+	}
+
+	err = env.MongoClient().FindOneAndUpdate(ctx, env.MongoOrdersCollectionName, filters, updates, &record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+	change.Record = record
+	return change, nil
+}
+
+// FindForUser returns the user's stored order with the given id, from any provider.
+func FindForUser(ctx context.Context, userId, orderId string) (*models.OrderRecord, error) {
+	var record *models.OrderRecord
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "order_id",
+			Value:    orderId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoOrdersCollectionName, filters, nil, &record)
+	return record, err
+}
+
+// RecentAddresses returns the distinct delivery addresses of the user's latest orders, newest first.
+func RecentAddresses(ctx context.Context, userId string, limit int64) ([]*models.DeliveryAddress, error) {
+	var records []*models.OrderRecord
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	sortKeys := []mongo.SortKey{{Key: "updated_at", Order: mongo.DSC}}
+	_, err := env.MongoClient().GetSorted(ctx, env.MongoOrdersCollectionName, filters, "", limit, sortKeys, &records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var addresses []*models.DeliveryAddress
+	for _, record := range records {
+		if record.Order == nil || record.Order.DeliveryAddress == nil || seen[record.Order.DeliveryAddress.Id] {
+			continue
+		}
+		seen[record.Order.DeliveryAddress.Id] = true
+		addresses = append(addresses, record.Order.DeliveryAddress)
+	}
+	return addresses, nil
+}
+
+// Touch refreshes when the user's order was last reported without recording a new snapshot,
+// used for duplicate updates which still prove the extension is tracking the order.
+func Touch(ctx context.Context, userId string, o *models.Order) error {
+	updates := mongo.Updates{
+		{
+			Key:            "last_seen_at",
+			Value:          time.Now(),
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoOrdersCollectionName, keyFilters(o.Provider, userId, o.OrderId), updates)
+}
+
+// MarkTrackingLost removes an order from the active set after the extension stopped reporting it.
+func MarkTrackingLost(ctx context.Context, record *models.OrderRecord) error {
+	updates := mongo.Updates{
+		{
+			Key:            "active",
+			Value:          false,
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		},
+		{
+			Key:            "tracking_lost",
+			Value:          true,
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoOrdersCollectionName, keyFilters(record.Provider, record.UserId, record.OrderId), updates)
+}
+
+// MarkEtaAlerted flags the order as late once its ETA breach alert was sent.
+func MarkEtaAlerted(ctx context.Context, record *models.OrderRecord, at time.Time) error {
+	updates := mongo.Updates{
+		{
+			Key:            "late",
+			Value:          true,
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		},
+		{
+			Key:            "eta_alerted_at",
+			Value:          at,
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	return env.MongoClient().Update(ctx, env.MongoOrdersCollectionName, keyFilters(record.Provider, record.UserId, record.OrderId), updates)
+}
+
+// SaveSplit attaches how the order's bill is shared to the order.
+func SaveSplit(ctx context.Context, record *models.OrderRecord, split *models.Split) error {
+	updates := mongo.Updates{
+		{
+			Key:            "split",
+			Value:          split,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	err := env.MongoClient().Update(ctx, env.MongoOrdersCollectionName, keyFilters(record.Provider, record.UserId, record.OrderId), updates)
+	if err != nil {
+		return err
+	}
+	record.Split = split
+	return nil
+}
+
+// SaveMessage stores a Slack message posted for the order so later updates can edit it.
+func SaveMessage(ctx context.Context, record *models.OrderRecord, msg *models.SlackMessage) error {
+	updates := mongo.Updates{
+		{
+			Key:            "messages",
+			Value:          []*models.SlackMessage{msg},
+			UpdateOperator: mongo.PUSH,
+		},
+	}
+	err := env.MongoClient().Update(ctx, env.MongoOrdersCollectionName, keyFilters(record.Provider, record.UserId, record.OrderId), updates)
+	if err != nil {
+		return err
+	}
+	record.Messages = append(record.Messages, msg)
+	return nil
+}
+
+// keyFilters match a single order, order ids are only unique per provider and user
+func keyFilters(provider, userId, orderId string) mongo.Filters {
+	return mongo.Filters{
+		{
+			Key:      "provider",
+			Value:    provider,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "order_id",
+			Value:    orderId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+}
+
+func newEvent(o *models.Order, stage lifecycle.Stage, at time.Time) *models.OrderEvent {
+	event := &models.OrderEvent{
+		Stage:   stage,
+		Label:   o.DeliveryLabel,
+		Message: o.DeliveryMessage,
+		At:      at,
+	}
+	if o.Status != nil {
+		event.Status = o.Status.Status
+		event.DeliveryStatus = o.Status.DeliveryStatus
+	}
+	return event
+}
+
+func lastEvent(record *models.OrderRecord) *models.OrderEvent {
+	if len(record.Timeline) == 0 {
+		return nil
+	}
+	return record.Timeline[len(record.Timeline)-1]
+}
+
+func isTransition(prev, next *models.OrderEvent) bool {
+	if prev == nil {
+		return true
+	}
+	return prev.Status != next.Status ||
+		prev.Flag != next.Flag ||
+		prev.DeliveryStatus != next.DeliveryStatus ||
+		prev.Label != next.Label ||
+		prev.Message != next.Message
+}
+
+// flagFor returns the timeline flag stored for a lifecycle anomaly
+func flagFor(err error) string {
+	switch {
+	case errors.Is(err, lifecycle.ErrUnknownStatus):
+		return models.OrderFlagUnknownStatus
+	case errors.Is(err, lifecycle.ErrRegression):
+		return models.OrderFlagRegression
+	case errors.Is(err, lifecycle.ErrTerminal):
+		return models.OrderFlagAfterTerminal
+	default:
+		return ""
+	}
+}
+
+// isLate reports whether an order was delivered after its expected delivery time
+func isLate(o *models.Order, stage lifecycle.Stage, now time.Time) bool {
+	return stage == lifecycle.Delivered && o.ExpectedDeliveryTime != nil && now.After(*o.ExpectedDeliveryTime)
+}
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+)
+
+// Provider adapts a delivery app's order updates to the normalized order model
+type Provider interface {
+	// Name is used in webhook routes and to key stored orders
+	Name() string
+	// Decode parses a webhook payload into the hashed slack id of the reporting user and the order
+	Decode(buf []byte) (string, *models.Order, error)
+	// MapStatus maps the provider's raw status codes to a lifecycle stage
+	MapStatus(status *models.ProviderStatus) (lifecycle.Stage, error)
+}
+
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case shared.ProviderZomato:
+		return &Zomato{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", name)
+	}
+}
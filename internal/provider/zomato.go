@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+)
+
+// zomatoStatuses maps `ZomatoOrder.Status` codes to stages. Freshly placed orders
+// can report 0 before Zomato assigns them a status.
+var zomatoStatuses = map[int]lifecycle.Stage{
+	0: lifecycle.Placed,
+	1: lifecycle.Placed,
+	2: lifecycle.Accepted,
+	3: lifecycle.Preparing,
+	4: lifecycle.PickedUp,
+	5: lifecycle.OutForDelivery,
+	6: lifecycle.Delivered,
+	7: lifecycle.Cancelled,
+	8: lifecycle.Failed,
+}
+
+// zomatoPaymentSuccess is the `ZomatoOrder.PaymentStatus` of a paid order
+const zomatoPaymentSuccess = 1
+
+type Zomato struct {
+}
+
+func (z *Zomato) Name() string {
+	return shared.ProviderZomato
+}
+
+func (z *Zomato) Decode(buf []byte) (string, *models.Order, error) {
+	var update *models.ZomatoOrderUpdate
+	if err := json.Unmarshal(buf, &update); err != nil {
+		return "", nil, err
+	}
+	if update == nil || update.Order == nil {
+		return "", nil, errors.New("order is missing")
+	}
+
+	zOrder := update.Order
+	order := &models.Order{
+		Provider: shared.ProviderZomato,
+		OrderId:  strconv.FormatUint(zOrder.OrderId, 10),
+		Status: &models.ProviderStatus{
+			Status:        strconv.Itoa(zOrder.Status),
+			PaymentStatus: strconv.Itoa(zOrder.PaymentStatus),
+		},
+	}
+	if zOrder.DeliveryAddress != nil && zOrder.DeliveryAddress.Id != "" {
+		order.DeliveryAddress = &models.DeliveryAddress{
+			Id:    string(zOrder.DeliveryAddress.Id),
+			Label: zOrder.DeliveryAddress.Label,
+		}
+	}
+	if !zOrder.ExpectedDeliveryTime.IsZero() {
+		eta := zOrder.ExpectedDeliveryTime.Time
+		order.ExpectedDeliveryTime = &eta
+	}
+	if zOrder.ResInfo != nil {
+		order.RestaurantName = zOrder.ResInfo.Name
+	}
+	if zOrder.DeliveryDetails != nil {
+		order.Status.DeliveryStatus = strconv.Itoa(zOrder.DeliveryDetails.DeliveryStatus)
+		order.DeliveryLabel = zOrder.DeliveryDetails.DeliveryLabel
+		order.DeliveryMessage = zOrder.DeliveryDetails.DeliveryMessage
+	}
+	order.Items, order.Bill = zomatoBill(zOrder)
+	return update.SlackId, order, nil
+}
+
+// MapStatus maps Zomato order and payment status codes to a stage.
+// Orders that are not paid yet stay placed until the restaurant can accept them.
+func (z *Zomato) MapStatus(status *models.ProviderStatus) (lifecycle.Stage, error) {
+	code, err := strconv.Atoi(status.Status)
+	if err != nil {
+		return lifecycle.Unknown, fmt.Errorf("%w: zomato status %q", lifecycle.ErrUnknownStatus, status.Status)
+	}
+	stage, ok := zomatoStatuses[code]
+	if !ok {
+		return lifecycle.Unknown, fmt.Errorf("%w: zomato status %d", lifecycle.ErrUnknownStatus, code)
+	}
+	if status.PaymentStatus != strconv.Itoa(zomatoPaymentSuccess) && !stage.IsTerminal() {
+		return lifecycle.Placed, nil
+	}
+	return stage, nil
+}
+
+// zomatoBill maps the ordered dishes and charges, the subtotal is derived from the items
+func zomatoBill(zOrder *models.ZomatoOrder) ([]*models.OrderItem, *models.Bill) {
+	var subtotal int64
+	items := make([]*models.OrderItem, 0, len(zOrder.Items))
+	for _, zItem := range zOrder.Items {
+		item := &models.OrderItem{
+			Name:       zItem.Name,
+			Quantity:   zItem.Quantity,
+			UnitPrice:  zItem.UnitPrice,
+			TotalPrice: zItem.TotalPrice,
+		}
+		if item.TotalPrice == 0 {
+			item.TotalPrice = item.UnitPrice * int64(item.Quantity)
+		}
+		subtotal += item.TotalPrice
+		items = append(items, item)
+	}
+
+	if zOrder.Bill == nil {
+		return items, nil
+	}
+	bill := &models.Bill{
+		Currency:    zOrder.Bill.Currency,
+		Subtotal:    subtotal,
+		Taxes:       zOrder.Bill.Taxes,
+		DeliveryFee: zOrder.Bill.DeliveryFee,
+		Discount:    zOrder.Bill.Discount,
+		Total:       zOrder.Bill.Total,
+	}
+	if bill.Total == 0 {
+		bill.Total = bill.Subtotal + bill.Taxes + bill.DeliveryFee - bill.Discount
+	}
+	return items, bill
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+)
+
+func TestZomatoMapStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        string
+		paymentStatus string
+		want          lifecycle.Stage
+		wantErr       error
+	}{
+		{name: "not assigned yet", status: "0", paymentStatus: "1", want: lifecycle.Placed},
+		{name: "placed", status: "1", paymentStatus: "1", want: lifecycle.Placed},
+		{name: "accepted", status: "2", paymentStatus: "1", want: lifecycle.Accepted},
+		{name: "preparing", status: "3", paymentStatus: "1", want: lifecycle.Preparing},
+		{name: "picked up", status: "4", paymentStatus: "1", want: lifecycle.PickedUp},
+		{name: "out for delivery", status: "5", paymentStatus: "1", want: lifecycle.OutForDelivery},
+		{name: "delivered", status: "6", paymentStatus: "1", want: lifecycle.Delivered},
+		{name: "cancelled", status: "7", paymentStatus: "1", want: lifecycle.Cancelled},
+		{name: "failed", status: "8", paymentStatus: "1", want: lifecycle.Failed},
+		{name: "unpaid orders stay placed", status: "3", paymentStatus: "0", want: lifecycle.Placed},
+		{name: "pending payment stays placed", status: "5", paymentStatus: "2", want: lifecycle.Placed},
+		{name: "unpaid cancelled order", status: "7", paymentStatus: "0", want: lifecycle.Cancelled},
+		{name: "unpaid failed order", status: "8", paymentStatus: "3", want: lifecycle.Failed},
+		{name: "unknown code", status: "9", paymentStatus: "1", want: lifecycle.Unknown, wantErr: lifecycle.ErrUnknownStatus},
+		{name: "negative code", status: "-1", paymentStatus: "1", want: lifecycle.Unknown, wantErr: lifecycle.ErrUnknownStatus},
+		{name: "not a number", status: "delivered", paymentStatus: "1", want: lifecycle.Unknown, wantErr: lifecycle.ErrUnknownStatus},
+		{name: "empty", status: "", paymentStatus: "", want: lifecycle.Unknown, wantErr: lifecycle.ErrUnknownStatus},
+	}
+	z := &Zomato{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := z.MapStatus(&models.ProviderStatus{Status: tt.status, PaymentStatus: tt.paymentStatus})
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("MapStatus(%q, %q) error = %v, want %v", tt.status, tt.paymentStatus, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MapStatus(%q, %q) = %q, want %q", tt.status, tt.paymentStatus, got, tt.want)
+			}
+		})
+	}
+}
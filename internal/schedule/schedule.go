@@ -0,0 +1,254 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/shared"
+)
+
+const minutesPerDay = 24 * 60
+
+var (
+	ErrInvalidTime     = errors.New("times must look like 09:00")
+	ErrEmptyWindow     = errors.New("a window can't start and end at the same time")
+	ErrInvalidTimezone = errors.New("unknown timezone")
+	ErrInvalidWeekday  = errors.New("unknown day")
+)
+
+// Error is a window that failed validation, Index is its position in the schedule
+type Error struct {
+	Index int
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("window %d: %v", e.Index+1, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Parse validates a window's start and end time and returns the window with
+// them normalised to shared.ScheduleTimeFormat. Windows ending before they
+// start run past midnight, e.g. 22:00 to 02:00.
+func Parse(from, to string) (*models.Schedule, error) {
+	start, err := parseMinutes(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseMinutes(to)
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, ErrEmptyWindow
+	}
+	return &models.Schedule{From: formatMinutes(start), To: formatMinutes(end)}, nil
+}
+
+// Validate checks a stored window's times, days and timezone
+func Validate(s *models.Schedule) error {
+	if _, err := Parse(s.From, s.To); err != nil {
+		return err
+	}
+	for _, day := range s.Weekdays {
+		if day < time.Sunday || day > time.Saturday {
+			return ErrInvalidWeekday
+		}
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return ErrInvalidTimezone
+		}
+	}
+	return nil
+}
+
+// Normalise validates the windows, formats their times and merges windows that
+// overlap or touch. Only windows on the same days and in the same timezone are
+// merged, they keep the order they first appear in.
+func Normalise(schedule []*models.Schedule) ([]*models.Schedule, error) {
+	var keys []string
+	groups := map[string][]*models.Schedule{}
+	for i, s := range schedule {
+		if err := Validate(s); err != nil {
+			return nil, &Error{Index: i, Err: err}
+		}
+		window, _ := Parse(s.From, s.To)
+		window.Weekdays = slices.Clone(s.Weekdays)
+		slices.Sort(window.Weekdays)
+		window.Weekdays = slices.Compact(window.Weekdays)
+		if len(window.Weekdays) == 7 {
+			window.Weekdays = nil
+		}
+		window.Timezone = s.Timezone
+
+		key := fmt.Sprint(window.Timezone, window.Weekdays)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], window)
+	}
+
+	result := []*models.Schedule{}
+	for _, key := range keys {
+		result = append(result, merge(groups[key])...)
+	}
+	return result, nil
+}
+
+// interval is a window in minutes since midnight of the day it starts on, end is inclusive
+// and goes past minutesPerDay for windows running past midnight
+type interval struct {
+	start, end int
+}
+
+// merge joins overlapping windows which all share the same days and timezone
+func merge(windows []*models.Schedule) []*models.Schedule {
+	intervals := make([]interval, 0, len(windows))
+	for _, w := range windows {
+		start, _ := parseMinutes(w.From)
+		end, _ := parseMinutes(w.To)
+		if end < start {
+			end += minutesPerDay
+		}
+		intervals = append(intervals, interval{start, end})
+	}
+	slices.SortFunc(intervals, func(a, b interval) int { return a.start - b.start })
+
+	merged := []interval{intervals[0]}
+	for _, next := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if next.start > last.end+1 {
+			merged = append(merged, next)
+			continue
+		}
+		last.end = max(last.end, next.end)
+	}
+
+	// windows on every day also join across midnight, e.g. 22:00-01:00 and 00:30-02:00
+	if len(windows[0].Weekdays) == 0 {
+		for len(merged) > 1 {
+			last, first := merged[len(merged)-1], merged[0]
+			if first.start+minutesPerDay > last.end+1 {
+				break
+			}
+			merged[len(merged)-1].end = max(last.end, first.end+minutesPerDay)
+			merged = merged[1:]
+		}
+	}
+
+	days, timezone := windows[0].Weekdays, windows[0].Timezone
+	result := make([]*models.Schedule, 0, len(merged))
+	for _, in := range merged {
+		switch {
+		case in.end-in.start+1 >= minutesPerDay && len(days) == 0:
+			result = append(result, toWindow(interval{0, minutesPerDay - 1}, days, timezone))
+		case in.end-in.start+1 > minutesPerDay:
+			// longer than a day on some days only, one window can't hold it so it is
+			// split at midnight and the part on the next day is kept. Both parts are
+			// kept longer than a minute, a window can't start and end at the same time.
+			midnight := minutesPerDay
+			if in.end == midnight {
+				midnight--
+			}
+			result = append(result,
+				toWindow(interval{in.start, midnight - 1}, days, timezone),
+				toWindow(interval{midnight, in.end}, days, timezone),
+			)
+		default:
+			result = append(result, toWindow(in, days, timezone))
+		}
+	}
+	return result
+}
+
+// toWindow turns an interval on days into a window, intervals starting after
+// midnight move to the following days
+func toWindow(in interval, days []time.Weekday, timezone string) *models.Schedule {
+	if in.start >= minutesPerDay && len(days) > 0 {
+		next := make([]time.Weekday, 0, len(days))
+		for _, day := range days {
+			next = append(next, (day+1)%7)
+		}
+		slices.Sort(next)
+		days = next
+	}
+	return &models.Schedule{
+		From:     formatMinutes(in.start),
+		To:       formatMinutes(in.end),
+		Weekdays: days,
+		Timezone: timezone,
+	}
+}
+
+// IsWithin reports whether t falls inside any of the schedule windows. Windows
+// are checked in zone unless they have their own timezone. Days of windows running
+// past midnight are the days they start on. An empty schedule means there is no restriction.
+func IsWithin(schedule []*models.Schedule, t time.Time, zone *time.Location) bool {
+	if len(schedule) == 0 {
+		return true
+	}
+
+	for _, s := range schedule {
+		loc := zone
+		if s.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(s.Timezone); err != nil {
+				log.Printf("[Schedule] Skipping schedule with invalid timezone %q: %v\n", s.Timezone, err)
+				continue
+			}
+		}
+		start, err := parseMinutes(s.From)
+		if err != nil {
+			log.Printf("[Schedule] Skipping invalid schedule start %q: %v\n", s.From, err)
+			continue
+		}
+		end, err := parseMinutes(s.To)
+		if err != nil {
+			log.Printf("[Schedule] Skipping invalid schedule end %q: %v\n", s.To, err)
+			continue
+		}
+
+		local := t.In(loc)
+		day, minute := local.Weekday(), local.Hour()*60+local.Minute()
+		if start <= end {
+			if s.OnDay(day) && minute >= start && minute <= end {
+				return true
+			}
+			continue
+		}
+		// past midnight, the early part belongs to the window that started the day before
+		if (s.OnDay(day) && minute >= start) || (s.OnDay((day+6)%7) && minute <= end) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOvernight reports whether the window runs past midnight
+func IsOvernight(s *models.Schedule) bool {
+	start, startErr := parseMinutes(s.From)
+	end, endErr := parseMinutes(s.To)
+	return startErr == nil && endErr == nil && end < start
+}
+
+func parseMinutes(value string) (int, error) {
+	t, err := time.Parse(shared.ScheduleTimeFormat, strings.TrimSpace(value))
+	if err != nil {
+		return 0, ErrInvalidTime
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatMinutes(minutes int) string {
+	minutes %= minutesPerDay
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
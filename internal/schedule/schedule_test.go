@@ -0,0 +1,239 @@
+package schedule
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+)
+
+func TestNormalise(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	everyDay := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+
+	tests := []struct {
+		name      string
+		input     []*models.Schedule
+		want      []*models.Schedule
+		wantErr   error
+		wantIndex int
+	}{
+		{
+			name:  "empty",
+			input: nil,
+			want:  []*models.Schedule{},
+		},
+		{
+			name:  "times are formatted",
+			input: []*models.Schedule{{From: "9:05", To: " 17:30 "}},
+			want:  []*models.Schedule{{From: "09:05", To: "17:30"}},
+		},
+		{
+			name:  "overlapping windows merge",
+			input: []*models.Schedule{{From: "11:00", To: "14:00"}, {From: "09:00", To: "12:00"}},
+			want:  []*models.Schedule{{From: "09:00", To: "14:00"}},
+		},
+		{
+			name:  "touching windows merge",
+			input: []*models.Schedule{{From: "09:00", To: "12:00"}, {From: "12:01", To: "13:00"}},
+			want:  []*models.Schedule{{From: "09:00", To: "13:00"}},
+		},
+		{
+			name:  "separate windows are kept",
+			input: []*models.Schedule{{From: "12:00", To: "13:00"}, {From: "09:00", To: "10:00"}},
+			want:  []*models.Schedule{{From: "09:00", To: "10:00"}, {From: "12:00", To: "13:00"}},
+		},
+		{
+			name:  "overnight windows merge across midnight",
+			input: []*models.Schedule{{From: "22:00", To: "01:00"}, {From: "00:30", To: "02:00"}},
+			want:  []*models.Schedule{{From: "22:00", To: "02:00"}},
+		},
+		{
+			name:  "windows covering the day become a full day",
+			input: []*models.Schedule{{From: "00:00", To: "12:00"}, {From: "11:00", To: "23:59"}},
+			want:  []*models.Schedule{{From: "00:00", To: "23:59"}},
+		},
+		{
+			name: "windows on some days longer than a day keep the next day",
+			input: []*models.Schedule{
+				{From: "18:00", To: "20:00", Weekdays: []time.Weekday{time.Friday}},
+				{From: "18:01", To: "18:00", Weekdays: []time.Weekday{time.Friday}},
+			},
+			want: []*models.Schedule{
+				{From: "18:00", To: "23:59", Weekdays: []time.Weekday{time.Friday}},
+				{From: "00:00", To: "18:00", Weekdays: []time.Weekday{time.Saturday}},
+			},
+		},
+		{
+			name: "windows on some days longer than a day wrap the week",
+			input: []*models.Schedule{
+				{From: "09:00", To: "12:00", Weekdays: []time.Weekday{time.Monday, time.Saturday}},
+				{From: "11:00", To: "09:30", Weekdays: []time.Weekday{time.Monday, time.Saturday}},
+			},
+			want: []*models.Schedule{
+				{From: "09:00", To: "23:59", Weekdays: []time.Weekday{time.Monday, time.Saturday}},
+				{From: "00:00", To: "09:30", Weekdays: []time.Weekday{time.Sunday, time.Tuesday}},
+			},
+		},
+		{
+			name: "windows on some days split into parts longer than a minute",
+			input: []*models.Schedule{
+				{From: "00:00", To: "23:00", Weekdays: []time.Weekday{time.Saturday}},
+				{From: "22:00", To: "00:00", Weekdays: []time.Weekday{time.Saturday}},
+			},
+			want: []*models.Schedule{
+				{From: "00:00", To: "23:58", Weekdays: []time.Weekday{time.Saturday}},
+				{From: "23:59", To: "00:00", Weekdays: []time.Weekday{time.Saturday}},
+			},
+		},
+		{
+			name: "windows on some days lasting a day stay overnight",
+			input: []*models.Schedule{
+				{From: "18:00", To: "23:00", Weekdays: []time.Weekday{time.Friday}},
+				{From: "22:00", To: "17:59", Weekdays: []time.Weekday{time.Friday}},
+			},
+			want: []*models.Schedule{
+				{From: "18:00", To: "17:59", Weekdays: []time.Weekday{time.Friday}},
+			},
+		},
+		{
+			name: "windows on other days are not merged",
+			input: []*models.Schedule{
+				{From: "09:00", To: "12:00", Weekdays: weekdays},
+				{From: "10:00", To: "13:00", Weekdays: []time.Weekday{time.Saturday}},
+			},
+			want: []*models.Schedule{
+				{From: "09:00", To: "12:00", Weekdays: weekdays},
+				{From: "10:00", To: "13:00", Weekdays: []time.Weekday{time.Saturday}},
+			},
+		},
+		{
+			name: "windows in other timezones are not merged",
+			input: []*models.Schedule{
+				{From: "09:00", To: "12:00", Timezone: "Asia/Kolkata"},
+				{From: "10:00", To: "13:00"},
+			},
+			want: []*models.Schedule{
+				{From: "09:00", To: "12:00", Timezone: "Asia/Kolkata"},
+				{From: "10:00", To: "13:00"},
+			},
+		},
+		{
+			name: "days are sorted and every day is no days",
+			input: []*models.Schedule{
+				{From: "09:00", To: "12:00", Weekdays: []time.Weekday{time.Friday, time.Monday, time.Friday}},
+				{From: "10:00", To: "13:00", Weekdays: everyDay},
+				{From: "12:00", To: "14:00"},
+			},
+			want: []*models.Schedule{
+				{From: "09:00", To: "12:00", Weekdays: []time.Weekday{time.Monday, time.Friday}},
+				{From: "10:00", To: "14:00"},
+			},
+		},
+		{
+			name:      "invalid time",
+			input:     []*models.Schedule{{From: "09:00", To: "10:00"}, {From: "25:00", To: "10:00"}},
+			wantErr:   ErrInvalidTime,
+			wantIndex: 1,
+		},
+		{
+			name:    "empty window",
+			input:   []*models.Schedule{{From: "09:00", To: "09:00"}},
+			wantErr: ErrEmptyWindow,
+		},
+		{
+			name:    "invalid timezone",
+			input:   []*models.Schedule{{From: "09:00", To: "10:00", Timezone: "Mars/Olympus"}},
+			wantErr: ErrInvalidTimezone,
+		},
+		{
+			name:    "invalid weekday",
+			input:   []*models.Schedule{{From: "09:00", To: "10:00", Weekdays: []time.Weekday{7}}},
+			wantErr: ErrInvalidWeekday,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalise(tt.input)
+			if tt.wantErr != nil {
+				var scheduleErr *Error
+				if !errors.As(err, &scheduleErr) || !errors.Is(err, tt.wantErr) || scheduleErr.Index != tt.wantIndex {
+					t.Fatalf("Normalise() error = %v, want %v at window %d", err, tt.wantErr, tt.wantIndex)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalise() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalise() = %s, want %s", formatAll(got), formatAll(tt.want))
+			}
+		})
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-01-01 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+	office := []*models.Schedule{{From: "09:00", To: "17:00"}}
+	overnight := []*models.Schedule{{From: "22:00", To: "02:00"}}
+	weekdays := []*models.Schedule{{From: "09:00", To: "17:00", Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}}
+	fridayNight := []*models.Schedule{{From: "22:00", To: "02:00", Weekdays: []time.Weekday{time.Friday}}}
+	longFriday, err := Normalise([]*models.Schedule{
+		{From: "18:00", To: "20:00", Weekdays: []time.Weekday{time.Friday}},
+		{From: "18:01", To: "18:00", Weekdays: []time.Weekday{time.Friday}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		schedule []*models.Schedule
+		t        time.Time
+		zone     *time.Location
+		want     bool
+	}{
+		{name: "no schedule", schedule: nil, t: at(1, 3, 0), zone: time.UTC, want: true},
+		{name: "inside window", schedule: office, t: at(1, 12, 0), zone: time.UTC, want: true},
+		{name: "before window", schedule: office, t: at(1, 8, 59), zone: time.UTC, want: false},
+		{name: "window start", schedule: office, t: at(1, 9, 0), zone: time.UTC, want: true},
+		{name: "window end is inclusive", schedule: office, t: at(1, 17, 0), zone: time.UTC, want: true},
+		{name: "after window", schedule: office, t: at(1, 17, 1), zone: time.UTC, want: false},
+		{name: "checked in the user's zone", schedule: office, t: at(1, 4, 0), zone: kolkata, want: true},
+		{name: "window timezone wins over the user's", schedule: []*models.Schedule{{From: "09:00", To: "17:00", Timezone: "Asia/Kolkata"}}, t: at(1, 12, 0), zone: time.UTC, want: false},
+		{name: "overnight before midnight", schedule: overnight, t: at(1, 23, 0), zone: time.UTC, want: true},
+		{name: "overnight after midnight", schedule: overnight, t: at(2, 1, 0), zone: time.UTC, want: true},
+		{name: "overnight outside", schedule: overnight, t: at(1, 3, 0), zone: time.UTC, want: false},
+		{name: "on a picked day", schedule: weekdays, t: at(5, 12, 0), zone: time.UTC, want: true},
+		{name: "on another day", schedule: weekdays, t: at(6, 12, 0), zone: time.UTC, want: false},
+		{name: "overnight belongs to the start day", schedule: fridayNight, t: at(6, 1, 0), zone: time.UTC, want: true},
+		{name: "overnight early part on the start day", schedule: fridayNight, t: at(5, 1, 0), zone: time.UTC, want: false},
+		{name: "day long window keeps the next morning", schedule: longFriday, t: at(6, 9, 0), zone: time.UTC, want: true},
+		{name: "day long window ends on the next day", schedule: longFriday, t: at(6, 18, 1), zone: time.UTC, want: false},
+		{name: "invalid timezone is skipped", schedule: []*models.Schedule{{From: "00:00", To: "23:59", Timezone: "Mars/Olympus"}}, t: at(1, 12, 0), zone: time.UTC, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWithin(tt.schedule, tt.t, tt.zone); got != tt.want {
+				t.Errorf("IsWithin(%s, %s) = %v, want %v", formatAll(tt.schedule), tt.t.In(tt.zone), got, tt.want)
+			}
+		})
+	}
+}
+
+func formatAll(windows []*models.Schedule) []string {
+	formatted := make([]string, 0, len(windows))
+	for _, w := range windows {
+		formatted = append(formatted, Format(w))
+	}
+	return formatted
+}
@@ -0,0 +1,124 @@
+package schedule
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWeekdays parses comma separated days and ranges of days like `mon-fri`,
+// `sat,sun` or `fri-mon`, and the `weekdays` and `weekends` shorthands.
+// The days are returned in week order, nil if every day was picked.
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	picked := map[time.Weekday]bool{}
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "weekdays":
+			part = "mon-fri"
+		case "weekends":
+			part = "sat-sun"
+		}
+
+		first, last, isRange := strings.Cut(part, "-")
+		from, ok := parseDay(first)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrInvalidWeekday, first)
+		}
+		to := from
+		if isRange {
+			if to, ok = parseDay(last); !ok {
+				return nil, fmt.Errorf("%w %q", ErrInvalidWeekday, last)
+			}
+		}
+		// ranges can wrap around the end of the week, e.g. fri-mon
+		for day := from; ; day = (day + 1) % 7 {
+			picked[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+
+	if len(picked) == 7 {
+		return nil, nil
+	}
+	var days []time.Weekday
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if picked[day] {
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+// parseDay reads a lower case day name, either short like `mon` or in full like `monday`
+func parseDay(name string) (time.Weekday, bool) {
+	if day, ok := weekdayNames[name]; ok {
+		return day, true
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if name == strings.ToLower(day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// FormatWeekdays renders days like `Mon–Fri` or `Mon, Wed, Fri`
+func FormatWeekdays(days []time.Weekday) string {
+	if len(days) == 0 || len(days) == 7 {
+		return "every day"
+	}
+
+	// weeks are rendered from Monday so weekends read as `Sat, Sun`
+	monday := func(day time.Weekday) int { return (int(day) + 6) % 7 }
+	days = slices.Clone(days)
+	slices.SortFunc(days, func(a, b time.Weekday) int { return monday(a) - monday(b) })
+
+	var parts []string
+	for i := 0; i < len(days); {
+		j := i
+		for j+1 < len(days) && monday(days[j+1]) == monday(days[j])+1 {
+			j++
+		}
+		switch {
+		case j-i >= 2:
+			parts = append(parts, days[i].String()[:3]+"–"+days[j].String()[:3])
+		case j > i:
+			parts = append(parts, days[i].String()[:3], days[j].String()[:3])
+		default:
+			parts = append(parts, days[i].String()[:3])
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Format renders a schedule window with its days and timezone
+func Format(s *models.Schedule) string {
+	text := s.From + " to " + s.To
+	if IsOvernight(s) {
+		text += " overnight"
+	}
+	if len(s.Weekdays) > 0 {
+		text += " on " + FormatWeekdays(s.Weekdays)
+	}
+	if s.Timezone != "" {
+		text += " (" + s.Timezone + ")"
+	}
+	return text
+}
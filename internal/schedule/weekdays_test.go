@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []time.Weekday
+		wantErr error
+	}{
+		{name: "range", input: "mon-fri", want: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{name: "list", input: "sat,sun", want: []time.Weekday{time.Sunday, time.Saturday}},
+		{name: "weekdays", input: "weekdays", want: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{name: "weekends", input: "weekends", want: []time.Weekday{time.Sunday, time.Saturday}},
+		{name: "range wrapping the week", input: "fri-mon", want: []time.Weekday{time.Sunday, time.Monday, time.Friday, time.Saturday}},
+		{name: "full names", input: "Monday, wednesday", want: []time.Weekday{time.Monday, time.Wednesday}},
+		{name: "full name range", input: "tuesday-thursday", want: []time.Weekday{time.Tuesday, time.Wednesday, time.Thursday}},
+		{name: "spaces and case", input: " MON , Fri ", want: []time.Weekday{time.Monday, time.Friday}},
+		{name: "duplicates", input: "mon,mon-tue", want: []time.Weekday{time.Monday, time.Tuesday}},
+		{name: "every day", input: "mon-sun", want: nil},
+		{name: "word starting with a day", input: "monkey", wantErr: ErrInvalidWeekday},
+		{name: "abbreviation that is not a day name", input: "tues", wantErr: ErrInvalidWeekday},
+		{name: "too short", input: "mo", wantErr: ErrInvalidWeekday},
+		{name: "bad range end", input: "mon-sundae", wantErr: ErrInvalidWeekday},
+		{name: "empty", input: "", wantErr: ErrInvalidWeekday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWeekdays(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseWeekdays(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseWeekdays(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// block and action ids of the settings modal
+const (
+	blockScheduleFrom = "schedule_from_"
+	blockScheduleTo   = "schedule_to_"
+	blockScheduleDays = "schedule_days_"
+	blockChannels     = "channels"
+	blockDM           = "dm"
+	blockAddresses    = "addresses"
+	blockStages       = "notify_stages"
+	blockMuted        = "muted_restaurants"
+	actionValue       = "value"
+)
+
+const (
+	// maxScheduleWindows is how many schedule windows the modal offers at most, unless more are saved
+	maxScheduleWindows = 5
+	// addressLookback is how many recent orders are searched for addresses to offer
+	addressLookback = 50
+)
+
+// OpenModal opens the settings modal for userId, prefilled with their current settings
+func OpenModal(ctx context.Context, api *slack.Client, triggerId, userId string) error {
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err := env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	if err == mongo.NoItemFound {
+		user = &models.User{UserId: userId}
+	} else if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	addresses, err := order.RecentAddresses(ctx, userId, addressLookback)
+	if err != nil {
+		// the modal is still useful without the address list
+		log.Printf("[Settings] Failed to get recent addresses of %s: %v\n", userId, err)
+	}
+
+	if _, err := api.OpenViewContext(ctx, triggerId, modal(user, addresses)); err != nil {
+		return fmt.Errorf("failed to open settings modal: %w", err)
+	}
+	return nil
+}
+
+func modal(user *models.User, addresses []*models.DeliveryAddress) slack.ModalViewRequest {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(plainText("Schedule")),
+		slack.NewContextBlock("", plainText("Updates are only posted inside these windows. Leave them empty to get updates at any time.")),
+	}
+	// windows added with `/track` beyond the limit are all shown so saving keeps them
+	windows := max(min(max(len(user.Schedule)+1, 2), maxScheduleWindows), len(user.Schedule))
+	for i := 0; i < windows; i++ {
+		from, to := slack.NewTimePickerBlockElement(actionValue), slack.NewTimePickerBlockElement(actionValue)
+		var dayOptions, selectedDays []*slack.OptionBlockObject
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			option := slack.NewOptionBlockObject(strings.ToLower(day.String()[:3]), plainText(day.String()), nil)
+			dayOptions = append(dayOptions, option)
+			if i < len(user.Schedule) && len(user.Schedule[i].Weekdays) > 0 && user.Schedule[i].OnDay(day) {
+				selectedDays = append(selectedDays, option)
+			}
+		}
+		days := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic, plainText("Every day"), actionValue, dayOptions...)
+		days.InitialOptions = selectedDays
+		if i < len(user.Schedule) {
+			from.InitialTime, to.InitialTime = user.Schedule[i].From, user.Schedule[i].To
+		}
+		blocks = append(blocks,
+			optional(slack.NewInputBlock(blockScheduleFrom+strconv.Itoa(i), plainText(fmt.Sprintf("Window %d from", i+1)), nil, from)),
+			optional(slack.NewInputBlock(blockScheduleTo+strconv.Itoa(i), plainText(fmt.Sprintf("Window %d to", i+1)), nil, to)),
+			optional(slack.NewInputBlock(blockScheduleDays+strconv.Itoa(i), plainText(fmt.Sprintf("Window %d days", i+1)), nil, days)),
+		)
+	}
+
+	blocks = append(blocks, slack.NewDividerBlock(), slack.NewHeaderBlock(plainText("Where updates go")))
+	channels := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeConversations, plainText("Pick channels"), actionValue)
+	var dmDestination bool
+	for _, d := range util.GetDestinations(user) {
+		if d.Type == shared.DestinationDM {
+			dmDestination = true
+		} else {
+			channels.InitialConversations = append(channels.InitialConversations, d.ChannelId)
+		}
+	}
+	dmOption := slack.NewOptionBlockObject("on", plainText("Send updates to me by direct message"), nil)
+	dm := slack.NewCheckboxGroupsBlockElement(actionValue, dmOption)
+	if dmDestination {
+		dm.InitialOptions = []*slack.OptionBlockObject{dmOption}
+	}
+	blocks = append(blocks,
+		optional(slack.NewInputBlock(blockChannels, plainText("Channels"), plainText("Invite SnackTrack to private channels first."), channels)),
+		optional(slack.NewInputBlock(blockDM, plainText("Direct message"), nil, dm)),
+	)
+
+	blocks = append(blocks, slack.NewDividerBlock(), slack.NewHeaderBlock(plainText("Addresses")))
+	addressOptions, selected := addressOptions(user.AddressIds, addresses)
+	if len(addressOptions) == 0 {
+		blocks = append(blocks, slack.NewContextBlock("", plainText("No addresses known yet, orders on every address are posted. Place an order with the extension installed to pick addresses here.")))
+	} else {
+		element := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic, plainText("Every address"), actionValue, addressOptions...)
+		element.InitialOptions = selected
+		blocks = append(blocks, optional(slack.NewInputBlock(blockAddresses, plainText("Only post orders delivered to"), plainText("Leave empty to post orders on every address."), element)))
+	}
+
+	blocks = append(blocks, slack.NewDividerBlock(), slack.NewHeaderBlock(plainText("Notifications")))
+	var stageOptions, selectedStages []*slack.OptionBlockObject
+	for _, stage := range lifecycle.Stages {
+		option := slack.NewOptionBlockObject(string(stage), plainText(stage.Label()), nil)
+		stageOptions = append(stageOptions, option)
+		if slices.Contains(user.NotifyStages, stage) {
+			selectedStages = append(selectedStages, option)
+		}
+	}
+	stages := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic, plainText("Every status"), actionValue, stageOptions...)
+	stages.InitialOptions = selectedStages
+	muted := slack.NewPlainTextInputBlockElement(plainText("One restaurant per line"), actionValue)
+	muted.Multiline = true
+	muted.InitialValue = strings.Join(user.MutedRestaurants, "\n")
+	blocks = append(blocks,
+		optional(slack.NewInputBlock(blockStages, plainText("Only post orders that are"), plainText("Leave empty to post every status."), stages)),
+		optional(slack.NewInputBlock(blockMuted, plainText("Muted restaurants"), nil, muted)),
+	)
+
+	return slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: shared.ViewSettings,
+		Title:      plainText("SnackTrack settings"),
+		Submit:     plainText("Save"),
+		Close:      plainText("Cancel"),
+		Blocks:     slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// addressOptions offers the user's saved addresses and the ones seen on their
+// orders, returning the options and the currently selected ones
+func addressOptions(addressIds []string, addresses []*models.DeliveryAddress) ([]*slack.OptionBlockObject, []*slack.OptionBlockObject) {
+	var options, selected []*slack.OptionBlockObject
+	seen := map[string]bool{}
+	add := func(id, label string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		if label == "" {
+			label = id
+		}
+		option := slack.NewOptionBlockObject(id, plainText(label), nil)
+		options = append(options, option)
+		if slices.Contains(addressIds, id) {
+			selected = append(selected, option)
+		}
+	}
+	for _, address := range addresses {
+		add(address.Id, address.Label)
+	}
+	for _, id := range addressIds {
+		add(id, "")
+	}
+	return options, selected
+}
+
+// ParseModal reads the settings submitted in the settings modal
+func ParseModal(view slack.View) *models.UpdateUserSettings {
+	values := map[string]slack.BlockAction{}
+	for blockId, actions := range view.State.Values {
+		values[blockId] = actions[actionValue]
+	}
+
+	u := &models.UpdateUserSettings{AddressIds: []string{}}
+	weekdays := [][]string{}
+	for i := 0; i < len(view.State.Values); i++ {
+		from := values[blockScheduleFrom+strconv.Itoa(i)].SelectedTime
+		to := values[blockScheduleTo+strconv.Itoa(i)].SelectedTime
+		if from == "" && to == "" {
+			continue
+		}
+		u.StartTime = append(u.StartTime, from)
+		u.EndTime = append(u.EndTime, to)
+		days := []string{}
+		for _, option := range values[blockScheduleDays+strconv.Itoa(i)].SelectedOptions {
+			days = append(days, option.Value)
+		}
+		weekdays = append(weekdays, days)
+	}
+	u.Weekdays = &weekdays
+
+	channels := values[blockChannels].SelectedConversations
+	if channels == nil {
+		channels = []string{}
+	}
+	u.Channels = &channels
+	directMessage := len(values[blockDM].SelectedOptions) > 0
+	u.DirectMessage = &directMessage
+
+	for _, option := range values[blockAddresses].SelectedOptions {
+		u.AddressIds = append(u.AddressIds, option.Value)
+	}
+	stages := []string{}
+	for _, option := range values[blockStages].SelectedOptions {
+		stages = append(stages, option.Value)
+	}
+	u.NotifyStages = &stages
+	muted := []string{}
+	for _, line := range strings.Split(values[blockMuted].Value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			muted = append(muted, line)
+		}
+	}
+	u.MutedRestaurants = &muted
+	return u
+}
+
+// ModalErrors maps a ValidationError for settings read by ParseModal to the
+// modal block it concerns
+func ModalErrors(view slack.View, err *ValidationError) map[string]string {
+	if err.Field != FieldSchedule {
+		return map[string]string{blockStages: err.Message}
+	}
+
+	// empty windows are skipped by ParseModal, find the window the index refers to
+	window := 0
+	for i := 0; i < len(view.State.Values); i++ {
+		from := view.State.Values[blockScheduleFrom+strconv.Itoa(i)][actionValue].SelectedTime
+		to := view.State.Values[blockScheduleTo+strconv.Itoa(i)][actionValue].SelectedTime
+		if from == "" && to == "" {
+			continue
+		}
+		if window == err.Index {
+			return map[string]string{blockScheduleTo + strconv.Itoa(i): err.Message}
+		}
+		window++
+	}
+	return map[string]string{blockScheduleTo + "0": err.Message}
+}
+
+func optional(block *slack.InputBlock) *slack.InputBlock {
+	block.Optional = true
+	return block
+}
+
+func plainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
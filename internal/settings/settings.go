@@ -0,0 +1,237 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/card"
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/home"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/schedule"
+	"github.com/diabolusgx/snack-track/internal/shared"
+	"github.com/diabolusgx/snack-track/internal/util"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// fields reported by ValidationError
+const (
+	FieldSchedule     = "schedule"
+	FieldNotifyStages = "notify_stages"
+)
+
+// ValidationError is returned for settings that can't be saved, Index is the
+// offending schedule window if Field is FieldSchedule
+type ValidationError struct {
+	Field   string
+	Index   int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// Update validates and saves settings sent by the extension or the settings
+// modal for userId, creating the user if they have none yet. Optional fields
+// left nil are not changed.
+func Update(ctx context.Context, userId string, u *models.UpdateUserSettings) (*models.User, error) {
+	windows, err := parseSchedule(u.StartTime, u.EndTime, u.Weekdays)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+	err = env.MongoClient().GetOne(ctx, env.MongoUsersCollectionName, filters, nil, &user)
+	newUser := err == mongo.NoItemFound
+	if newUser {
+		user = &models.User{UserId: userId}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// windows sent unchanged keep their timezone, and their days if none were sent
+	for _, s := range windows {
+		for _, existing := range user.Schedule {
+			if existing.From != s.From || existing.To != s.To {
+				continue
+			}
+			s.Timezone = existing.Timezone
+			if u.Weekdays == nil {
+				s.Weekdays = existing.Weekdays
+			}
+			break
+		}
+	}
+	user.Schedule, err = schedule.Normalise(windows)
+	var scheduleErr *schedule.Error
+	if errors.As(err, &scheduleErr) {
+		return nil, &ValidationError{Field: FieldSchedule, Index: scheduleErr.Index, Message: scheduleErr.Err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.AddressIds = u.AddressIds
+	updates := mongo.Updates{
+		{
+			Key:            "schedule",
+			Value:          user.Schedule,
+			UpdateOperator: mongo.SET,
+		},
+		{
+			Key:            "address_ids",
+			Value:          u.AddressIds,
+			Type:           mongo.STRING_ARRAY,
+			UpdateOperator: mongo.SET,
+		},
+	}
+
+	// notification preferences and channels are optional, older extension builds don't send them
+	if u.NotifyStages != nil {
+		user.NotifyStages, err = lifecycle.ParseAll(*u.NotifyStages)
+		if err != nil {
+			return nil, &ValidationError{Field: FieldNotifyStages, Message: err.Error()}
+		}
+		updates.Append(mongo.Update{
+			Key:            "notify_stages",
+			Value:          user.NotifyStages,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if u.MutedRestaurants != nil {
+		user.MutedRestaurants = *u.MutedRestaurants
+		updates.Append(mongo.Update{
+			Key:            "muted_restaurants",
+			Value:          user.MutedRestaurants,
+			Type:           mongo.STRING_ARRAY,
+			UpdateOperator: mongo.SET,
+		})
+	}
+	if u.Channels != nil || u.DirectMessage != nil {
+		user.Destinations = routeTo(user, u.Channels, u.DirectMessage)
+		user.ChannelId = ""
+		updates.Append(mongo.Update{
+			Key:            "destinations",
+			Value:          user.Destinations,
+			UpdateOperator: mongo.SET,
+		}, mongo.Update{
+			Key:            "channel_id",
+			Value:          "",
+			Type:           mongo.STRING,
+			UpdateOperator: mongo.SET,
+		})
+	}
+
+	if newUser {
+		if err := env.MongoClient().Insert(ctx, env.MongoUsersCollectionName, user); err != nil {
+			return nil, fmt.Errorf("failed to insert user: %w", err)
+		}
+		return user, nil
+	}
+	if err := env.MongoClient().FindOneAndUpdate(ctx, env.MongoUsersCollectionName, filters, updates, &user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// parseSchedule pairs up start and end times, and the days if sent, into schedule windows.
+// Windows ending before they start run past midnight.
+func parseSchedule(startTimes, endTimes []string, weekdays *[][]string) ([]*models.Schedule, error) {
+	if len(startTimes) != len(endTimes) {
+		return nil, &ValidationError{Field: FieldSchedule, Index: min(len(startTimes), len(endTimes)), Message: "every start time needs an end time"}
+	}
+	if weekdays != nil && len(*weekdays) != len(startTimes) {
+		return nil, &ValidationError{Field: FieldSchedule, Index: min(len(startTimes), len(*weekdays)), Message: "every window needs its days"}
+	}
+
+	var windows []*models.Schedule
+	for i := range startTimes {
+		if strings.TrimSpace(startTimes[i]) == "" || strings.TrimSpace(endTimes[i]) == "" {
+			return nil, &ValidationError{Field: FieldSchedule, Index: i, Message: "every start time needs an end time"}
+		}
+		window, err := schedule.Parse(startTimes[i], endTimes[i])
+		if err != nil {
+			return nil, &ValidationError{Field: FieldSchedule, Index: i, Message: err.Error()}
+		}
+		if weekdays != nil && len((*weekdays)[i]) > 0 {
+			window.Weekdays, err = schedule.ParseWeekdays(strings.Join((*weekdays)[i], ","))
+			if err != nil {
+				return nil, &ValidationError{Field: FieldSchedule, Index: i, Message: err.Error()}
+			}
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// routeTo rebuilds the user's destinations for the given channels and DM choice.
+// Destinations that are kept keep their stage filters, nil arguments keep the
+// current channels or DM destination.
+func routeTo(user *models.User, channels *[]string, directMessage *bool) []*models.Destination {
+	var current []*models.Destination
+	if len(user.Destinations) > 0 || user.ChannelId != "" {
+		current = util.GetDestinations(user)
+	}
+
+	existing := map[string]*models.Destination{}
+	var dm *models.Destination
+	for _, d := range current {
+		if d.Type == shared.DestinationDM {
+			dm = d
+		} else {
+			existing[d.ChannelId] = d
+		}
+	}
+
+	var destinations []*models.Destination
+	if channels == nil {
+		for _, d := range current {
+			if d.Type == shared.DestinationChannel {
+				destinations = append(destinations, d)
+			}
+		}
+	} else {
+		for _, channelId := range *channels {
+			d, ok := existing[channelId]
+			if !ok {
+				d = &models.Destination{Type: shared.DestinationChannel, ChannelId: channelId}
+			}
+			destinations = append(destinations, d)
+		}
+	}
+
+	wantsDM := dm != nil
+	if directMessage != nil {
+		wantsDM = *directMessage
+	}
+	if wantsDM {
+		if dm == nil {
+			dm = &models.Destination{Type: shared.DestinationDM}
+		}
+		destinations = append(destinations, dm)
+	}
+	return destinations
+}
+
+// Announce refreshes the user's Home tab and sends them their updated settings by DM
+func Announce(ctx context.Context, api *slack.Client, user *models.User) error {
+	home.PublishAsync(api, user.UserId)
+
+	blocks, text := card.Settings(user, "Your settings have been updated.\n\n")
+	if _, _, err := api.PostMessageContext(ctx, user.UserId, slack.MsgOptionBlocks(blocks...), slack.MsgOptionText(text, false)); err != nil {
+		return fmt.Errorf("failed to send settings: %w", err)
+	}
+	return nil
+}
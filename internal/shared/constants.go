@@ -2,4 +2,41 @@ package shared
 
 const (
 	ScheduleTimeFormat = "15:04"
+	VacationDateFormat = "2006-01-02"
+
+	// delivery providers
+	ProviderZomato = "zomato"
+
+	// message modes for order updates
+	MessageModeEdit   = "edit"   // one message per order, edited on every update
+	MessageModeThread = "thread" // one message per order, edited and with a thread reply per transition
+	MessageModeLegacy = "legacy" // a new message for every update
+
+	// order update destination types
+	DestinationChannel = "channel"
+	DestinationDM      = "dm"
+
+	// digest types
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+
+	// where digests are posted
+	DigestDeliveryChannel = "channel" // the user's updates channel, DM if it is not set
+	DigestDeliveryDM      = "dm"
+
+	// callback id of the global shortcut showing the user's settings
+	ShortcutSettings = "st_settings"
+
+	// block action id of the button opening the settings modal, and the modal's callback id
+	ActionSettingsEdit = "settings_edit"
+	ViewSettings       = "st_settings_modal"
+
+	// block action id of the button confirming a delivered order was picked up
+	ActionOrderAck = "order_ack"
+
+	// block action ids of lunch session messages
+	ActionLunchJoin  = "lunch_join"
+	ActionLunchLeave = "lunch_leave"
+	ActionLunchVote  = "lunch_vote"
+	ActionLunchClose = "lunch_close"
 )
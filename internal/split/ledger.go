@@ -0,0 +1,269 @@
+package split
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+)
+
+// Balance is the net amount a counterparty owes a user in one currency,
+// negative when the user owes the counterparty
+type Balance struct {
+	UserId   string
+	Currency string
+	Amount   int64
+}
+
+// EnsureIndexes creates the indexes used by the ledger collection.
+func EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.Index{
+		{
+			Keys: []mongo.SortKey{{Key: "team_id", Order: mongo.ASC}, {Key: "debtor_id", Order: mongo.ASC}, {Key: "settled", Order: mongo.ASC}},
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "team_id", Order: mongo.ASC}, {Key: "creditor_id", Order: mongo.ASC}, {Key: "settled", Order: mongo.ASC}},
+		},
+		{
+			Keys: []mongo.SortKey{{Key: "provider", Order: mongo.ASC}, {Key: "order_id", Order: mongo.ASC}},
+		},
+	}
+	return env.MongoClient().CreateIndexes(ctx, env.MongoLedgerCollectionName, indexes)
+}
+
+// RecordDebts replaces the unsettled ledger entries of a split order with what
+// each participant owes the payer. Entries already settled are kept and their
+// debtors are not charged again, they are returned so the payer can be told.
+func RecordDebts(ctx context.Context, teamId string, record *models.OrderRecord, split *models.Split) ([]string, error) {
+	orderFilters := mongo.Filters{
+		{
+			Key:      "provider",
+			Value:    record.Provider,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "order_id",
+			Value:    record.OrderId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "creditor_id",
+			Value:    split.PayerId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+	}
+
+	var settled []*models.LedgerEntry
+	filters := append(slices.Clone(orderFilters), mongo.Filter{
+		Key:      "settled",
+		Value:    true,
+		Type:     mongo.BOOL,
+		Operator: mongo.EQUAL,
+	})
+	if _, err := env.MongoClient().Get(ctx, env.MongoLedgerCollectionName, filters, "", 0, &settled); err != nil {
+		return nil, fmt.Errorf("failed to get settled entries: %w", err)
+	}
+	var paid []string
+	for _, entry := range settled {
+		if !slices.Contains(paid, entry.DebtorId) {
+			paid = append(paid, entry.DebtorId)
+		}
+	}
+
+	filters = append(slices.Clone(orderFilters), mongo.Filter{
+		Key:      "settled",
+		Value:    false,
+		Type:     mongo.BOOL,
+		Operator: mongo.EQUAL,
+	})
+	if _, err := env.MongoClient().DeleteMany(ctx, env.MongoLedgerCollectionName, filters); err != nil {
+		return nil, fmt.Errorf("failed to clear previous split: %w", err)
+	}
+
+	var entries []interface{}
+	for _, participant := range split.Participants {
+		if participant.UserId == split.PayerId || participant.Amount <= 0 || slices.Contains(paid, participant.UserId) {
+			continue
+		}
+		entries = append(entries, &models.LedgerEntry{
+			TeamId:     teamId,
+			DebtorId:   participant.UserId,
+			CreditorId: split.PayerId,
+			Amount:     participant.Amount,
+			Currency:   split.Currency,
+			Provider:   record.Provider,
+			OrderId:    record.OrderId,
+			CreatedAt:  split.CreatedAt,
+		})
+	}
+	if len(entries) == 0 {
+		return paid, nil
+	}
+	if err := env.MongoClient().InsertMany(ctx, env.MongoLedgerCollectionName, entries); err != nil {
+		return nil, fmt.Errorf("failed to insert ledger entries: %w", err)
+	}
+	return paid, nil
+}
+
+// Balances nets the user's unsettled debts and credits in the workspace per counterparty and currency
+func Balances(ctx context.Context, teamId, userId string) ([]*Balance, error) {
+	var entries []*models.LedgerEntry
+	filters := mongo.Filters{
+		{
+			Key:      "team_id",
+			Value:    teamId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "settled",
+			Value:    false,
+			Type:     mongo.BOOL,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key: "$or",
+			Value: mongo.Filters{
+				{
+					Key:      "debtor_id",
+					Value:    userId,
+					Type:     mongo.STRING,
+					Operator: mongo.EQUAL,
+				},
+				{
+					Key:      "creditor_id",
+					Value:    userId,
+					Type:     mongo.STRING,
+					Operator: mongo.EQUAL,
+				},
+			},
+			Operator: mongo.OR,
+		},
+	}
+	_, err := env.MongoClient().Get(ctx, env.MongoLedgerCollectionName, filters, "", 0, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+
+	return netBalances(entries, userId), nil
+}
+
+// netBalances nets entries per counterparty and currency from userId's side,
+// leaving out balances that cancel out
+func netBalances(entries []*models.LedgerEntry, userId string) []*Balance {
+	balances := map[[2]string]*Balance{}
+	for _, entry := range entries {
+		counterparty, amount := entry.DebtorId, entry.Amount
+		if entry.DebtorId == userId {
+			counterparty, amount = entry.CreditorId, -entry.Amount
+		}
+		key := [2]string{counterparty, entry.Currency}
+		if balances[key] == nil {
+			balances[key] = &Balance{UserId: counterparty, Currency: entry.Currency}
+		}
+		balances[key].Amount += amount
+	}
+
+	result := make([]*Balance, 0, len(balances))
+	for _, balance := range balances {
+		if balance.Amount != 0 {
+			result = append(result, balance)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Amount > result[j].Amount
+	})
+	return result
+}
+
+// Settle clears what debtorId owes creditorId in the workspace, netted the same
+// way as Balances: per currency, the entries in both directions are marked as paid
+// together. Currencies in which the creditor owes the debtor are left open.
+// Returns the settled balances from the creditor's side.
+func Settle(ctx context.Context, teamId, creditorId, debtorId string) ([]*Balance, error) {
+	// the pair's entries in both directions, nobody owes themselves
+	filters := mongo.Filters{
+		{
+			Key:      "team_id",
+			Value:    teamId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "creditor_id",
+			Value:    []string{creditorId, debtorId},
+			Operator: mongo.IN,
+		},
+		{
+			Key:      "debtor_id",
+			Value:    []string{creditorId, debtorId},
+			Operator: mongo.IN,
+		},
+		{
+			Key:      "settled",
+			Value:    false,
+			Type:     mongo.BOOL,
+			Operator: mongo.EQUAL,
+		},
+	}
+	var entries []*models.LedgerEntry
+	if _, err := env.MongoClient().Get(ctx, env.MongoLedgerCollectionName, filters, "", 0, &entries); err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+
+	// entries recorded after reading them are not part of the balance being settled
+	latest := map[string]time.Time{}
+	for _, entry := range entries {
+		if entry.CreatedAt.After(latest[entry.Currency]) {
+			latest[entry.Currency] = entry.CreatedAt
+		}
+	}
+
+	updates := mongo.Updates{
+		{
+			Key:            "settled",
+			Value:          true,
+			Type:           mongo.BOOL,
+			UpdateOperator: mongo.SET,
+		},
+		{
+			Key:            "settled_at",
+			Value:          time.Now(),
+			Type:           mongo.TIME,
+			UpdateOperator: mongo.SET,
+		},
+	}
+	var settled []*Balance
+	for _, balance := range netBalances(entries, creditorId) {
+		if balance.Amount <= 0 {
+			continue
+		}
+		currencyFilters := append(slices.Clone(filters),
+			mongo.Filter{
+				Key:      "currency",
+				Value:    balance.Currency,
+				Type:     mongo.STRING,
+				Operator: mongo.EQUAL,
+			},
+			mongo.Filter{
+				Key:      "created_at",
+				Value:    latest[balance.Currency],
+				Type:     mongo.TIME,
+				Operator: mongo.LESS_THAN_EQUAL,
+			},
+		)
+		if err := env.MongoClient().Update(ctx, env.MongoLedgerCollectionName, currencyFilters, updates); err != nil {
+			return settled, fmt.Errorf("failed to settle ledger entries: %w", err)
+		}
+		settled = append(settled, balance)
+	}
+	return settled, nil
+}
@@ -0,0 +1,156 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+)
+
+// ErrNoBill is returned for orders reported without prices
+var ErrNoBill = errors.New("order has no bill")
+
+// Compute sets each participant's Amount for the order. Item prices go to the
+// people assigned to them, the rest of the subtotal is divided by shares and
+// taxes, fees and discounts follow each person's part of the subtotal. Amounts
+// always add up to the bill's total.
+func Compute(o *models.Order, participants []*models.SplitParticipant) error {
+	if o.Bill == nil || o.Bill.Total == 0 {
+		return ErrNoBill
+	}
+	if len(participants) == 0 {
+		return errors.New("split has no participants")
+	}
+
+	seen := map[string]bool{}
+	for _, participant := range participants {
+		if seen[participant.UserId] {
+			return fmt.Errorf("<@%s> is in the split more than once", participant.UserId)
+		}
+		seen[participant.UserId] = true
+		for _, n := range participant.Items {
+			if n < 1 || n > len(o.Items) {
+				return fmt.Errorf("order has no item %d", n)
+			}
+			if o.Items[n-1].TotalPrice == 0 {
+				return fmt.Errorf("item %d has no price, split the order by shares instead", n)
+			}
+		}
+	}
+
+	subtotal := o.Bill.Subtotal
+	if subtotal == 0 {
+		for _, item := range o.Items {
+			subtotal += item.TotalPrice
+		}
+	}
+
+	shares := make([]int64, len(participants))
+	var totalShares int64
+	for i, participant := range participants {
+		shares[i] = int64(participant.Shares)
+		totalShares += shares[i]
+	}
+
+	// bills reported with only a total have nothing to weigh but the shares
+	if subtotal == 0 {
+		if totalShares == 0 {
+			return errors.New("the order has no item prices, give everyone a share instead")
+		}
+		for i, amount := range allocate(o.Bill.Total, shares) {
+			participants[i].Amount = amount
+		}
+		return nil
+	}
+
+	base := make([]int64, len(participants))
+	rest := subtotal
+	var unassigned []string
+	for i, item := range o.Items {
+		var owners []int
+		for p, participant := range participants {
+			if slices.Contains(participant.Items, i+1) {
+				owners = append(owners, p)
+			}
+		}
+		if len(owners) == 0 {
+			unassigned = append(unassigned, strconv.Itoa(i+1))
+			continue
+		}
+		for j, amount := range allocate(item.TotalPrice, equalWeights(len(owners))) {
+			base[owners[j]] += amount
+		}
+		rest -= item.TotalPrice
+	}
+
+	if rest != 0 {
+		if totalShares == 0 {
+			return fmt.Errorf("items %s are not assigned to anyone", strings.Join(unassigned, ", "))
+		}
+		for i, amount := range allocate(rest, shares) {
+			base[i] += amount
+		}
+	}
+
+	// taxes, fees and discounts, taken from the total so amounts add up to it
+	weights := base
+	if slices.Max(base) <= 0 {
+		weights = equalWeights(len(participants))
+	}
+	extra := allocate(o.Bill.Total-subtotal, weights)
+	for i, participant := range participants {
+		participant.Amount = base[i] + extra[i]
+	}
+	return nil
+}
+
+// allocate divides total in proportion to weights using the largest remainder
+// method, so the parts add up to total exactly
+func allocate(total int64, weights []int64) []int64 {
+	parts := make([]int64, len(weights))
+	var sum int64
+	for _, w := range weights {
+		sum += max(w, 0)
+	}
+	if sum == 0 || total == 0 {
+		return parts
+	}
+
+	sign := int64(1)
+	if total < 0 {
+		sign, total = -1, -total
+	}
+
+	remainders := make([]int, 0, len(weights))
+	left := total
+	for i, w := range weights {
+		parts[i] = total * max(w, 0) / sum
+		left -= parts[i]
+		remainders = append(remainders, i)
+	}
+	sort.SliceStable(remainders, func(a, b int) bool {
+		ra := total * max(weights[remainders[a]], 0) % sum
+		rb := total * max(weights[remainders[b]], 0) % sum
+		return ra > rb
+	})
+	for i := 0; left > 0; i, left = i+1, left-1 {
+		parts[remainders[i%len(remainders)]]++
+	}
+
+	for i := range parts {
+		parts[i] *= sign
+	}
+	return parts
+}
+
+func equalWeights(n int) []int64 {
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return weights
+}
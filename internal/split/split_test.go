@@ -0,0 +1,158 @@
+package split
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/diabolusgx/snack-track/internal/models"
+)
+
+func TestCompute(t *testing.T) {
+	priced := &models.Order{
+		Items: []*models.OrderItem{
+			{Name: "Biryani", Quantity: 1, TotalPrice: 30000},
+			{Name: "Naan", Quantity: 2, TotalPrice: 10000},
+		},
+		Bill: &models.Bill{Currency: "INR", Subtotal: 40000, Total: 44000},
+	}
+	unpriced := &models.Order{
+		Items: []*models.OrderItem{{Name: "Biryani", Quantity: 1}, {Name: "Naan", Quantity: 2}},
+		Bill:  &models.Bill{Currency: "INR", Total: 100000},
+	}
+
+	tests := []struct {
+		name         string
+		order        *models.Order
+		participants []*models.SplitParticipant
+		want         []int64
+		wantErr      bool
+		wantErrIs    error
+	}{
+		{
+			name:         "no bill",
+			order:        &models.Order{},
+			participants: []*models.SplitParticipant{{UserId: "A", Shares: 1}},
+			wantErr:      true,
+			wantErrIs:    ErrNoBill,
+		},
+		{
+			name:         "no participants",
+			order:        priced,
+			participants: nil,
+			wantErr:      true,
+		},
+		{
+			name:         "equal shares",
+			order:        priced,
+			participants: []*models.SplitParticipant{{UserId: "A", Shares: 1}, {UserId: "B", Shares: 1}},
+			want:         []int64{22000, 22000},
+		},
+		{
+			name:         "items go to their owners, taxes follow",
+			order:        priced,
+			participants: []*models.SplitParticipant{{UserId: "A", Items: []int{1}}, {UserId: "B", Items: []int{2}}},
+			want:         []int64{33000, 11000},
+		},
+		{
+			name:         "unassigned items are divided by shares",
+			order:        priced,
+			participants: []*models.SplitParticipant{{UserId: "A", Items: []int{1}}, {UserId: "B", Shares: 1}},
+			want:         []int64{33000, 11000},
+		},
+		{
+			name:         "unassigned items without shares",
+			order:        priced,
+			participants: []*models.SplitParticipant{{UserId: "A", Items: []int{1}}},
+			wantErr:      true,
+		},
+		{
+			name:         "unknown item",
+			order:        priced,
+			participants: []*models.SplitParticipant{{UserId: "A", Items: []int{3}}},
+			wantErr:      true,
+		},
+		{
+			name:         "no prices are divided by shares",
+			order:        unpriced,
+			participants: []*models.SplitParticipant{{UserId: "A", Shares: 3}, {UserId: "B", Shares: 1}},
+			want:         []int64{75000, 25000},
+		},
+		{
+			name:         "no prices with a total only",
+			order:        &models.Order{Bill: &models.Bill{Currency: "INR", Total: 100000}},
+			participants: []*models.SplitParticipant{{UserId: "A", Shares: 1}, {UserId: "B", Shares: 2}, {UserId: "C", Shares: 1}},
+			want:         []int64{25000, 50000, 25000},
+		},
+		{
+			name:         "no prices can't be split by items",
+			order:        unpriced,
+			participants: []*models.SplitParticipant{{UserId: "A", Items: []int{1}}, {UserId: "B", Shares: 1}},
+			wantErr:      true,
+		},
+		{
+			name:         "rounding remainders add up to the total",
+			order:        &models.Order{Bill: &models.Bill{Currency: "INR", Total: 100}},
+			participants: []*models.SplitParticipant{{UserId: "A", Shares: 1}, {UserId: "B", Shares: 1}, {UserId: "C", Shares: 1}},
+			want:         []int64{34, 33, 33},
+		},
+		{
+			name:         "duplicate participants",
+			order:        priced,
+			participants: []*models.SplitParticipant{{UserId: "A", Shares: 1}, {UserId: "A", Shares: 2}},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Compute(tt.order, tt.participants)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compute() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("Compute() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := make([]int64, 0, len(tt.participants))
+			var sum int64
+			for _, participant := range tt.participants {
+				got = append(got, participant.Amount)
+				sum += participant.Amount
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Compute() amounts = %v, want %v", got, tt.want)
+			}
+			if sum != tt.order.Bill.Total {
+				t.Errorf("Compute() amounts add up to %d, want %d", sum, tt.order.Bill.Total)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{name: "even", total: 90, weights: []int64{1, 1, 1}, want: []int64{30, 30, 30}},
+		{name: "weighted", total: 100, weights: []int64{3, 1}, want: []int64{75, 25}},
+		{name: "remainder to the largest fractions", total: 100, weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "remainder by weight", total: 10, weights: []int64{1, 2}, want: []int64{3, 7}},
+		{name: "negative total", total: -100, weights: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "zero total", total: 0, weights: []int64{1, 2}, want: []int64{0, 0}},
+		{name: "zero weights", total: 100, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "negative weights count as zero", total: 100, weights: []int64{-5, 1}, want: []int64{0, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allocate(tt.total, tt.weights); !slices.Equal(got, tt.want) {
+				t.Errorf("allocate(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/util"
+)
+
+// Format renders a summary as a Slack message body
+func Format(summary *Summary) string {
+	if summary.Orders == 0 {
+		return "No orders in this period. :salad:\n"
+	}
+
+	strBuilder := &strings.Builder{}
+	strBuilder.WriteString(fmt.Sprintf("*Orders:* %d\n", summary.Orders))
+	if summary.TotalSpend > 0 {
+		strBuilder.WriteString(fmt.Sprintf("*Total spend:* %s\n", util.FormatAmount(summary.TotalSpend, summary.Currency)))
+		strBuilder.WriteString(fmt.Sprintf("*Average order:* %s\n", util.FormatAmount(summary.AverageSpend, summary.Currency)))
+	}
+	if missing := summary.Orders - summary.BilledOrders; missing > 0 {
+		strBuilder.WriteString(fmt.Sprintf("_%d of %d orders were reported without a bill and are left out of the spend, bills are sent from extension version %s._\n", missing, summary.Orders, util.BillExtensionVersion))
+	}
+
+	strBuilder.WriteString(fmt.Sprintf("*Late deliveries:* %d\n", summary.LateDeliveries))
+
+	if len(summary.TopRestaurants) > 0 {
+		strBuilder.WriteString("*Top restaurants:*\n")
+		for i, restaurant := range summary.TopRestaurants {
+			name := restaurant.Name
+			if name == "" {
+				name = "Unknown restaurant"
+			}
+			strBuilder.WriteString(fmt.Sprintf("%d. %s — %d orders", i+1, name, restaurant.Orders))
+			if restaurant.Spend > 0 {
+				strBuilder.WriteString(", " + util.FormatAmount(restaurant.Spend, summary.Currency))
+			}
+			strBuilder.WriteString("\n")
+		}
+	}
+
+	strBuilder.WriteString(fmt.Sprintf("*Busiest day:* %s\n", summary.BusiestWeekday))
+	strBuilder.WriteString(fmt.Sprintf("*Busiest hour:* %02d:00–%02d:00\n", summary.BusiestHour, (summary.BusiestHour+1)%24))
+	if summary.AverageDelivery > 0 {
+		strBuilder.WriteString(fmt.Sprintf("*Average delivery time:* %d min\n", int(summary.AverageDelivery.Round(time.Minute).Minutes())))
+	}
+	return strBuilder.String()
+}
@@ -0,0 +1,201 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/lifecycle"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+)
+
+// topRestaurantsLimit is how many restaurants are listed in a summary
+const topRestaurantsLimit = 3
+
+// Summary aggregates a user's orders placed in [From, To)
+type Summary struct {
+	From time.Time
+	To   time.Time
+
+	Orders       int
+	Currency     string
+	TotalSpend   int64
+	AverageSpend int64
+	// BilledOrders counts orders reported with a bill, spend only covers these
+	BilledOrders int
+	// LateDeliveries counts orders delivered after their expected delivery time
+	LateDeliveries int
+
+	TopRestaurants []*Restaurant
+	// BusiestWeekday and BusiestHour are only meaningful if Orders > 0
+	BusiestWeekday time.Weekday
+	BusiestHour    int
+	// AverageDelivery is the mean time from placing to delivery, 0 if no order was delivered
+	AverageDelivery time.Duration
+}
+
+// Restaurant is a restaurant's share of a user's orders
+type Restaurant struct {
+	Name   string `bson:"-"`
+	Orders int    `bson:"orders"`
+	Spend  int64  `bson:"spend"`
+}
+
+// ForUser aggregates the user's stored orders placed between from and to.
+// Weekdays and hours are computed in loc. Cancelled and failed orders are ignored.
+func ForUser(ctx context.Context, userId string, from, to time.Time, loc *time.Location) (*Summary, error) {
+	filters := mongo.Filters{
+		{
+			Key:      "user_id",
+			Value:    userId,
+			Type:     mongo.STRING,
+			Operator: mongo.EQUAL,
+		},
+		{
+			Key:      "created_at",
+			Value:    mongo.Range{Left: from, Right: to},
+			Type:     mongo.TIME,
+			Operator: mongo.BETWEEN,
+		},
+		{
+			Key:      "stage",
+			Value:    []lifecycle.Stage{lifecycle.Cancelled, lifecycle.Failed},
+			Operator: mongo.NOT_IN,
+		},
+	}
+	summary := &Summary{From: from, To: to}
+
+	if err := totals(ctx, filters, summary); err != nil {
+		return nil, err
+	}
+	if summary.Orders == 0 {
+		return summary, nil
+	}
+
+	restaurants, err := topRestaurants(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	summary.TopRestaurants = restaurants
+
+	weekday, err := busiest(ctx, filters, "$dayOfWeek", loc)
+	if err != nil {
+		return nil, err
+	}
+	// $dayOfWeek counts from 1 (Sunday)
+	summary.BusiestWeekday = time.Weekday(weekday - 1)
+
+	summary.BusiestHour, err = busiest(ctx, filters, "$hour", loc)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func totals(ctx context.Context, filters mongo.Filters, summary *Summary) error {
+	aggregateKeys := mongo.AggregateKeys{
+		{Key: "orders", Operator: mongo.SUM, Value: 1},
+		{Key: "spend", Operator: mongo.SUM, Value: "$order.bill.total"},
+		{Key: "average_spend", Operator: mongo.AVG, Value: "$order.bill.total"},
+		{Key: "currency", Operator: mongo.MAX, Value: "$order.bill.currency"},
+		{Key: "billed", Operator: mongo.SUM, Value: expr("$cond", []interface{}{expr("$gt", []interface{}{"$order.bill.total", 0}), 1, 0})},
+		{Key: "late", Operator: mongo.SUM, Value: expr("$cond", []interface{}{"$late", 1, 0})},
+		{Key: "average_delivery", Operator: mongo.AVG, Value: expr("$subtract", []string{"$delivered_at", "$created_at"})},
+	}
+	cursor, err := env.MongoClient().GetAggregate(ctx, env.MongoOrdersCollectionName, filters, nil, aggregateKeys, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate order totals: %w", err)
+	}
+
+	var results []struct {
+		Orders          int     `bson:"orders"`
+		Spend           int64   `bson:"spend"`
+		AverageSpend    float64 `bson:"average_spend"`
+		Currency        string  `bson:"currency"`
+		Billed          int     `bson:"billed"`
+		Late            int     `bson:"late"`
+		AverageDelivery float64 `bson:"average_delivery"`
+	}
+	if err := mongo.DecodeAll(ctx, cursor, &results); err != nil {
+		return fmt.Errorf("failed to decode order totals: %w", err)
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	summary.Orders = results[0].Orders
+	summary.TotalSpend = results[0].Spend
+	summary.AverageSpend = int64(results[0].AverageSpend)
+	summary.Currency = results[0].Currency
+	summary.BilledOrders = results[0].Billed
+	summary.LateDeliveries = results[0].Late
+	summary.AverageDelivery = time.Duration(results[0].AverageDelivery) * time.Millisecond
+	return nil
+}
+
+func topRestaurants(ctx context.Context, filters mongo.Filters) ([]*Restaurant, error) {
+	groupKeys := mongo.GroupKeys{
+		{Key: "name", Value: "$order.restaurant_name"},
+	}
+	aggregateKeys := mongo.AggregateKeys{
+		{Key: "orders", Operator: mongo.SUM, Value: 1},
+		{Key: "spend", Operator: mongo.SUM, Value: "$order.bill.total"},
+	}
+	sortKeys := []mongo.SortKey{{Key: "orders", Order: mongo.DSC}, {Key: "spend", Order: mongo.DSC}}
+	cursor, err := env.MongoClient().GetAggregate(ctx, env.MongoOrdersCollectionName, filters, groupKeys, aggregateKeys, sortKeys, topRestaurantsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate restaurants: %w", err)
+	}
+
+	var results []struct {
+		Id struct {
+			Name string `bson:"name"`
+		} `bson:"_id"`
+		Restaurant `bson:",inline"`
+	}
+	if err := mongo.DecodeAll(ctx, cursor, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode restaurants: %w", err)
+	}
+
+	restaurants := make([]*Restaurant, 0, len(results))
+	for _, result := range results {
+		restaurant := result.Restaurant
+		restaurant.Name = result.Id.Name
+		restaurants = append(restaurants, &restaurant)
+	}
+	return restaurants, nil
+}
+
+// busiest returns the value of a date operator like $hour with the most orders
+func busiest(ctx context.Context, filters mongo.Filters, dateOperator string, loc *time.Location) (int, error) {
+	groupKeys := mongo.GroupKeys{
+		{Key: "value", Value: expr(dateOperator, map[string]string{"date": "$created_at", "timezone": loc.String()})},
+	}
+	aggregateKeys := mongo.AggregateKeys{
+		{Key: "orders", Operator: mongo.SUM, Value: 1},
+	}
+	sortKeys := []mongo.SortKey{{Key: "orders", Order: mongo.DSC}}
+	cursor, err := env.MongoClient().GetAggregate(ctx, env.MongoOrdersCollectionName, filters, groupKeys, aggregateKeys, sortKeys, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to aggregate %s: %w", dateOperator, err)
+	}
+
+	var results []struct {
+		Id struct {
+			Value int `bson:"value"`
+		} `bson:"_id"`
+	}
+	if err := mongo.DecodeAll(ctx, cursor, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode %s: %w", dateOperator, err)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Id.Value, nil
+}
+
+// expr builds a single operator expression like {"$hour": {...}}
+func expr(operator string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{operator: value}
+}
@@ -0,0 +1,71 @@
+package util
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/diabolusgx/snack-track/internal/env"
+	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/pkg/mongo"
+	"github.com/slack-go/slack"
+)
+
+// timezoneRefreshInterval is how long a cached Slack timezone is trusted before
+// it is fetched again from `users.info`.
+const timezoneRefreshInterval = 24 * time.Hour
+
+// GetUserLocation returns the user's timezone as reported by Slack. The timezone is
+// cached on the user document and refreshed once it gets older than a day.
+// Falls back to UTC if the timezone cannot be resolved.
+func GetUserLocation(ctx context.Context, api *slack.Client, user *models.User) *time.Location {
+	if user.Timezone == "" || time.Since(user.TimezoneCheckedAt) > timezoneRefreshInterval {
+		info, err := api.GetUserInfoContext(ctx, user.UserId)
+		if err != nil {
+			log.Printf("[Timezone] Failed to get slack user info for %s: %v\n", user.UserId, err)
+		} else if info.TZ != "" {
+			user.Timezone = info.TZ
+			user.TimezoneCheckedAt = time.Now()
+			filters := mongo.Filters{
+				{
+					Key:      "user_id",
+					Value:    user.UserId,
+					Type:     mongo.STRING,
+					Operator: mongo.EQUAL,
+				},
+			}
+			updates := mongo.Updates{
+				{
+					Key:            "timezone",
+					Value:          user.Timezone,
+					Type:           mongo.STRING,
+					UpdateOperator: mongo.SET,
+				},
+				{
+					Key:            "timezone_checked_at",
+					Value:          user.TimezoneCheckedAt,
+					Type:           mongo.TIME,
+					UpdateOperator: mongo.SET,
+				},
+			}
+			if err := env.MongoClient().Update(ctx, env.MongoUsersCollectionName, filters, updates); err != nil {
+				log.Printf("[Timezone] Failed to cache timezone for %s: %v\n", user.UserId, err)
+			}
+		}
+	}
+
+	return userLocation(user)
+}
+
+// userLocation returns the user's cached timezone without refreshing it, UTC if it is unknown
+func userLocation(user *models.User) *time.Location {
+	if user.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		log.Printf("[Timezone] Failed to load location %q for %s: %v\n", user.Timezone, user.UserId, err)
+		return time.UTC
+	}
+	return loc
+}
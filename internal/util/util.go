@@ -5,16 +5,24 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/diabolusgx/snack-track/internal/env"
 	"github.com/diabolusgx/snack-track/internal/models"
+	"github.com/diabolusgx/snack-track/internal/schedule"
+	"github.com/diabolusgx/snack-track/internal/shared"
 )
 
-func GetSlackMsgForSettings(user *models.User) string {
-	channelMsg := "But you need to set updates channel first. Please use `/set-channel` command in channel which you want to use for order updates.\n"
-	if user.ChannelId != "" {
-		channelMsg = "Your updates channel is set to <#" + user.ChannelId + ">\n"
+// GetSettingsGroups renders the user's settings as one text per group of related settings
+func GetSettingsGroups(user *models.User) []string {
+	channelMsg := "Order updates are sent to you by direct message. Use `/st-channel add` in a channel to get them there instead.\n"
+	if len(user.Destinations) > 0 || user.ChannelId != "" {
+		channelMsg = "Order updates are sent to:\n"
+		for _, d := range GetDestinations(user) {
+			channelMsg += "- " + FormatDestination(d) + "\n"
+		}
 	}
 
 	addressMsg := "There's no address filter. Hence, orders on all addresses will be sent to your updates channel.\n"
@@ -26,13 +34,126 @@ func GetSlackMsgForSettings(user *models.User) string {
 	if len(user.Schedule) > 0 {
 		timeMsg = "You will receive updates for orders between the following times:\n"
 		for _, s := range user.Schedule {
-			timeMsg += "- " + s.From + " to " + s.To + "\n"
+			timeMsg += "- " + schedule.Format(s) + "\n"
+		}
+	}
+
+	vacationMsg := ""
+	if user.Vacation != nil && user.Vacation.To >= time.Now().In(userLocation(user)).Format(shared.VacationDateFormat) {
+		vacationMsg = fmt.Sprintf(":palm_tree: You're on vacation from %s to %s, no order updates are sent on those days.\n", user.Vacation.From, user.Vacation.To)
+	}
+
+	modeMsg := "Each order gets a single message which is edited on every update, with a thread reply for each status change.\n"
+	switch user.MessageMode {
+	case shared.MessageModeEdit:
+		modeMsg = "Each order gets a single message which is edited on every update.\n"
+	case shared.MessageModeLegacy:
+		modeMsg = "Every order update is posted as a new message.\n"
+	}
+
+	itemsMsg := "Ordered items are not shown in order updates.\n"
+	if user.ShowItems {
+		itemsMsg = "Ordered items and the order total are shown in order updates.\n"
+	}
+
+	notifyMsg := "Updates are posted for every order status.\n"
+	if len(user.NotifyStages) > 0 {
+		stages := make([]string, 0, len(user.NotifyStages))
+		for _, stage := range user.NotifyStages {
+			stages = append(stages, stage.Label())
 		}
+		notifyMsg = "Updates are only posted when an order is: " + strings.Join(stages, ", ") + "\n"
+	}
+	if len(user.MutedRestaurants) > 0 {
+		notifyMsg += "Orders from these restaurants are muted: " + strings.Join(user.MutedRestaurants, ", ") + "\n"
+	}
+
+	interval, limit := user.ReminderSettings()
+	reminderMsg := fmt.Sprintf("Delivered orders nobody picked up are reminded of every %d min, up to %d times", int(interval.Minutes()), limit)
+	if user.ReminderBackup != "" {
+		reminderMsg += ", also pinging <@" + user.ReminderBackup + ">"
+	}
+	reminderMsg += ".\n"
+	if user.RemindersOff {
+		reminderMsg = "Delivered orders are not reminded of.\n"
+	}
+
+	digestMsg := "You are not subscribed to any digest. Use `/st-digest --weekly=on` or `/st-digest --monthly=on` to get one.\n"
+	if len(user.Digests) > 0 {
+		destination := "your updates channel"
+		if user.DigestDelivery == shared.DigestDeliveryDM || GetUpdatesChannel(user) == "" {
+			destination = "direct message"
+		}
+		digestMsg = "You will receive " + strings.Join(user.Digests, " and ") + " digests by " + destination + ".\n"
+	}
+
+	return []string{
+		"*Where updates go*\n" + channelMsg,
+		"*Which orders are tracked*\n" + addressMsg + timeMsg + vacationMsg,
+		"*How updates look*\n" + modeMsg + itemsMsg,
+		"*Notifications*\n" + notifyMsg + reminderMsg,
+		"*Digests*\n" + digestMsg,
+	}
+}
+
+// settingsHelp lists the commands changing each setting
+const settingsHelp = "*Changing settings*\n" +
+	"- `/st-settings --edit` or the *Snack Track extension*: channels, addresses, schedule and notifications\n" +
+	"- `/st-channel add|remove|list`: update channels\n" +
+	"- `/track add|list|remove|clear`: schedule windows\n" +
+	"- `/st-settings --mode=edit|thread|legacy --items=on|off`: how updates look\n" +
+	"- `/st-settings --reminder=<minutes>|off --reminders=<count> --backup=@teammate|off`: reminders\n" +
+	"- `/st-notify`: order statuses and muted restaurants\n" +
+	"- `/st-vacation` and `/st-snooze`: pause updates\n" +
+	"- `/st-digest --weekly=on|off --monthly=on|off --to=channel|dm`: digests\n"
+
+// GetSlackMsgForSettings renders all of the user's settings with the commands changing them
+func GetSlackMsgForSettings(user *models.User) string {
+	return "Here are your settings:\n\n" + strings.Join(GetSettingsGroups(user), "\n") + "\n" + settingsHelp
+}
+
+// GetSettingsHelp returns the list of commands changing the settings
+func GetSettingsHelp() string {
+	return settingsHelp
+}
+
+// GetDestinations returns where the user's order updates are posted. Users who
+// only set a channel with the old `/st-channel` get that channel, users without
+// any destination get a DM.
+func GetDestinations(user *models.User) []*models.Destination {
+	if len(user.Destinations) > 0 {
+		return user.Destinations
 	}
+	if user.ChannelId != "" {
+		return []*models.Destination{{Type: shared.DestinationChannel, ChannelId: user.ChannelId}}
+	}
+	return []*models.Destination{{Type: shared.DestinationDM}}
+}
 
-	defaultInfo := "\nTo update any of these settings, please use *Snack Track extension* in your browser (except for channel, which is updated by `/st-channel` command).\n"
+// GetUpdatesChannel returns the user's first updates channel, empty if updates only go to their DM
+func GetUpdatesChannel(user *models.User) string {
+	for _, d := range GetDestinations(user) {
+		if d.Type == shared.DestinationChannel {
+			return d.ChannelId
+		}
+	}
+	return ""
+}
 
-	return "Here are your settings:\n" + channelMsg + addressMsg + timeMsg + defaultInfo
+// FormatDestination renders a destination and the stages posted to it
+func FormatDestination(d *models.Destination) string {
+	text := "direct message"
+	if d.Type == shared.DestinationChannel {
+		text = "<#" + d.ChannelId + ">"
+	}
+	if len(d.Stages) == 0 {
+		return text + " (all updates)"
+	}
+	stages := make([]string, 0, len(d.Stages))
+	for _, stage := range d.Stages {
+		stages = append(stages, stage.Label())
+	}
+	return text + " (" + strings.Join(stages, ", ") + ")"
 }
 
 func GetSlackIdFromHash(slackId string) (string, bool, error) {
@@ -67,3 +188,50 @@ func verifyHash(data, hash string) (bool, error) {
 	expectedHash := hex.EncodeToString(mac.Sum(nil))
 	return hash == expectedHash, nil
 }
+
+// IsAllowedAddress reports whether an order delivered to addressId should be posted
+// for a user with the given address filter. An empty filter allows every address.
+func IsAllowedAddress(addressIds []string, addressId string) bool {
+	if len(addressIds) == 0 {
+		return true
+	}
+	for _, id := range addressIds {
+		if id == addressId {
+			return true
+		}
+	}
+	return false
+}
+
+var currencySymbols = map[string]string{
+	"INR": "₹",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// BillExtensionVersion is the first browser extension release that reports order items and bills
+const BillExtensionVersion = "1.2.0"
+
+// FormatAmount formats an amount in minor currency units, e.g. 54050 INR as ₹540.50
+func FormatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	symbol, ok := currencySymbols[strings.ToUpper(currency)]
+	if !ok {
+		symbol = strings.ToUpper(currency) + " "
+	}
+	return fmt.Sprintf("%s%s%d.%02d", sign, symbol, amount/100, amount%100)
+}
+
+// Mentions renders user ids as space separated Slack mentions
+func Mentions(userIds []string) string {
+	parts := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		parts = append(parts, "<@"+userId+">")
+	}
+	return strings.Join(parts, " ")
+}
@@ -7,6 +7,10 @@ import (
 
 	"github.com/diabolusgx/snack-track/internal/env"
 	"github.com/diabolusgx/snack-track/internal/handler"
+	"github.com/diabolusgx/snack-track/internal/job"
+	"github.com/diabolusgx/snack-track/internal/lunch"
+	"github.com/diabolusgx/snack-track/internal/order"
+	"github.com/diabolusgx/snack-track/internal/split"
 	"github.com/diabolusgx/snack-track/pkg/mongo"
 	"github.com/slack-go/slack"
 )
@@ -24,10 +28,29 @@ func main() {
 	}
 	client := mongo.NewMongoDB(context.TODO(), env.MongoDatabaseName, connectionURI)
 	env.WithMongoClient(client)
+	if err := order.EnsureIndexes(context.TODO()); err != nil {
+		fmt.Println("[ERROR] Failed to create orders indexes:", err)
+	}
+	if err := lunch.EnsureIndexes(context.TODO()); err != nil {
+		fmt.Println("[ERROR] Failed to create lunch sessions indexes:", err)
+	}
+	if err := split.EnsureIndexes(context.TODO()); err != nil {
+		fmt.Println("[ERROR] Failed to create ledger indexes:", err)
+	}
 
 	handler.RegisterEventAPIHandler(api)
 	handler.RegisterCommandAPIHandler(api)
 	handler.RegisterWebhookHandler(api)
+	handler.RegisterInteractiveHandler(api)
+
+	job.Start(context.Background(), api,
+		&job.EtaBreach{},
+		&job.StaleOrders{},
+		&job.Digest{},
+		&job.LunchCutoff{},
+		&job.SnoozeSummary{},
+		&job.DeliveryReminders{},
+	)
 
 	fmt.Println("[INFO] Server listening")
 	http.ListenAndServe(":2929", nil)
@@ -23,9 +23,11 @@ type BaseMongoDBClient interface {
 	GetWriterDB() (db interface{}, err error)
 	FindOneAndUpdate(ctx context.Context, table string, filters Filters, updates Updates, result interface{}) (err error)
 	GetCursor(ctx context.Context, table string, filters Filters, sortKeys []SortKey, projections interface{}) (cursor interface{}, err error)
-	GetAggregate(ctx context.Context, collection string, filters Filters, groupKeys GroupKeys, aggregateKeys AggregateKeys) (cursor interface{}, err error)
+	GetAggregate(ctx context.Context, collection string, filters Filters, groupKeys GroupKeys, aggregateKeys AggregateKeys, sortKeys []SortKey, limit int64) (cursor interface{}, err error)
 	DeleteMany(ctx context.Context, collection string, filters Filters) (deletedCount int64, err error)
 	Distinct(ctx context.Context, collection string, fieldName string, filters Filters) (result []interface{}, err error)
+	CreateIndexes(ctx context.Context, collection string, indexes []Index) (err error)
+	DropIndex(ctx context.Context, collection string, keys []SortKey) (err error)
 }
 
 // DataType datatypes used
@@ -43,6 +45,11 @@ func (u UpdateOperator) ToString() string {
 
 var NoItemFound = mongo.ErrNoDocuments
 
+// IsDuplicateKey reports whether err was caused by a unique index violation
+func IsDuplicateKey(err error) bool {
+	return mongo.IsDuplicateKeyError(err)
+}
+
 const (
 	INT64        DataType = 1
 	STRING       DataType = 2
@@ -77,6 +84,8 @@ const (
 	INC      UpdateOperator = "$inc"
 	ARRAY_IN UpdateOperator = "$in"
 	NOT      UpdateOperator = "$not"
+	// ADD_TO_SET adds a single value to an array unless it is already there
+	ADD_TO_SET UpdateOperator = "$addToSet"
 )
 
 type Range struct {
@@ -153,13 +162,15 @@ const (
 	SUM AggregateOperator = 1
 	MIN AggregateOperator = 2
 	MAX AggregateOperator = 3
+	AVG AggregateOperator = 4
 )
 
-// AggregateKey represents a fiedl which needs to be aggregated
+// AggregateKey represents a fiedl which needs to be aggregated.
+// Value is a field path like "$total", a constant or an aggregation expression.
 type AggregateKey struct {
 	Key      string
 	Operator AggregateOperator
-	Value    string
+	Value    interface{}
 }
 
 // Append append function
@@ -167,6 +178,14 @@ func (a *AggregateKeys) Append(aggregateKeys ...AggregateKey) {
 	*a = append(*a, aggregateKeys...)
 }
 
+// Index represents an index on a collection, keys are applied in order.
+// ExpireAfterSeconds makes it a TTL index when set.
+type Index struct {
+	Keys               []SortKey
+	Unique             bool
+	ExpireAfterSeconds *int32
+}
+
 type BulkWriteResult struct {
 	mongo.BulkWriteResult
 	Err error
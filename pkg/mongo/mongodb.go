@@ -2,8 +2,11 @@ package mongo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -29,6 +32,8 @@ const (
 	DeleteMany       dbOperation = "DeleteMany"
 	GetDistinct      dbOperation = "GetDistinct"
 	Delete           dbOperation = "Delete"
+	CreateIndexes    dbOperation = "CreateIndexes"
+	DropIndex        dbOperation = "DropIndex"
 )
 
 func (c dbOperation) String() string {
@@ -393,7 +398,9 @@ func (db *MongoDB) GetCursor(ctx context.Context, collection string, filters Fil
 	return cur, nil
 }
 
-func (db *MongoDB) GetAggregate(ctx context.Context, collection string, filters Filters, groupKeys GroupKeys, aggregateKeys AggregateKeys) (interface{}, error) {
+// GetAggregate groups the filtered documents by groupKeys and computes aggregateKeys for each group.
+// Groups are sorted by sortKeys, which can refer to aggregated keys, and limited to limit if it is not 0.
+func (db *MongoDB) GetAggregate(ctx context.Context, collection string, filters Filters, groupKeys GroupKeys, aggregateKeys AggregateKeys, sortKeys []SortKey, limit int64) (interface{}, error) {
 	// defer newrelic.StartMongoDBDataSegment(ctx, collection, GetAggregate.String()).End()
 	c := db.client.Database(db.dbName).Collection(collection)
 	// Filter results in match stage
@@ -426,6 +433,10 @@ func (db *MongoDB) GetAggregate(ctx context.Context, collection string, filters
 			aggregateMap = append(aggregateMap, bson.E{
 				Key:   each.Key,
 				Value: bson.M{"$max": each.Value}})
+		case AVG:
+			aggregateMap = append(aggregateMap, bson.E{
+				Key:   each.Key,
+				Value: bson.M{"$avg": each.Value}})
 		default:
 			log.Printf("Operator[%d] not implemented in mongo.GetAggregate", each.Operator)
 		}
@@ -433,7 +444,24 @@ func (db *MongoDB) GetAggregate(ctx context.Context, collection string, filters
 	groupStage := bson.D{{
 		Key:   "$group",
 		Value: aggregateMap}}
-	cur, err := c.Aggregate(ctx, mongo.Pipeline{matchStage, groupStage})
+	pipeline := mongo.Pipeline{matchStage, groupStage}
+	if len(sortKeys) > 0 {
+		sort := bson.D{}
+		for _, each := range sortKeys {
+			sort = append(sort, bson.E{
+				Key:   each.Key,
+				Value: each.Order})
+		}
+		pipeline = append(pipeline, bson.D{{
+			Key:   "$sort",
+			Value: sort}})
+	}
+	if limit != 0 {
+		pipeline = append(pipeline, bson.D{{
+			Key:   "$limit",
+			Value: limit}})
+	}
+	cur, err := c.Aggregate(ctx, pipeline)
 	if err != nil {
 		log.Printf("Err. Mongo Aggregate: %s \n", err)
 		return nil, err
@@ -441,6 +469,15 @@ func (db *MongoDB) GetAggregate(ctx context.Context, collection string, filters
 	return cur, nil
 }
 
+// DecodeAll decodes every document of a cursor returned by GetCursor or GetAggregate into results and closes it
+func DecodeAll(ctx context.Context, cursor interface{}, results interface{}) error {
+	cur, ok := cursor.(*mongo.Cursor)
+	if !ok {
+		return mongo.ErrNilCursor
+	}
+	return cur.All(ctx, results)
+}
+
 func (db *MongoDB) Delete(ctx context.Context, collection string, filters Filters) (deletedCount int64, err error) {
 	// defer newrelic.StartMongoDBDataSegment(ctx, collection, Delete.String()).End()
 	c := db.client.Database(db.dbName).Collection(collection)
@@ -495,6 +532,55 @@ func (db *MongoDB) Distinct(ctx context.Context, collection string, fieldName st
 	return c.Distinct(ctx, fieldName, bson.M(m))
 }
 
+// CreateIndexes creates the given indexes, existing indexes with the same keys and options are left untouched
+func (db *MongoDB) CreateIndexes(ctx context.Context, collection string, indexes []Index) error {
+	// defer newrelic.StartMongoDBDataSegment(ctx, collection, CreateIndexes.String()).End()
+	c := db.client.Database(db.dbName).Collection(collection)
+	indexModels := make([]mongo.IndexModel, 0, len(indexes))
+	for _, each := range indexes {
+		keys := bson.D{}
+		for _, key := range each.Keys {
+			keys = append(keys, bson.E{
+				Key:   key.Key,
+				Value: key.Order,
+			})
+		}
+		indexOptions := options.Index().SetUnique(each.Unique)
+		if each.ExpireAfterSeconds != nil {
+			indexOptions.SetExpireAfterSeconds(*each.ExpireAfterSeconds)
+		}
+		indexModels = append(indexModels, mongo.IndexModel{
+			Keys:    keys,
+			Options: indexOptions,
+		})
+	}
+	_, err := c.Indexes().CreateMany(ctx, indexModels)
+	if err != nil {
+		log.Println("Err. c.Indexes().CreateMany,", err.Error())
+	}
+	return err
+}
+
+// DropIndex drops the index on the given keys, an index that doesn't exist is not an error
+func (db *MongoDB) DropIndex(ctx context.Context, collection string, keys []SortKey) error {
+	// defer newrelic.StartMongoDBDataSegment(ctx, collection, DropIndex.String()).End()
+	c := db.client.Database(db.dbName).Collection(collection)
+	// indexes created without a name are named after their keys, e.g. `provider_1_order_id_1`
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Order))
+	}
+	_, err := c.Indexes().DropOne(ctx, strings.Join(parts, "_"))
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+	if err != nil {
+		log.Println("Err. c.Indexes().DropOne,", err.Error())
+	}
+	return err
+}
+
 func mongoUpdates(updates Updates) map[string]interface{} {
 	docUpdates := make(map[string]interface{})
 	for _, each := range updates {